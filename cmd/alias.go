@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias <alias> <name>",
+	Short: "Define an alias for an SDM resource",
+	Long: `Gives an SDM resource a short alias. Aliases are shown in the menus and are
+accepted anywhere a resource name is accepted.`,
+	Example: `  # Alias a long resource name
+  sdm-ui alias pay-ro rds-payments-primary-us-east-1-readonly`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Alias operation failed", func(application *app.App) error {
+			return application.SetAlias(args[0], args[1])
+		})
+	},
+}
+
+// unaliasCmd represents the unalias command
+var unaliasCmd = &cobra.Command{
+	Use:   "unalias <alias>",
+	Short: "Remove an alias",
+	Long:  `Removes an alias stored in the database. Aliases declared in the config file must be removed from it.`,
+	Example: `  # Remove an alias
+  sdm-ui unalias pay-ro`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Unalias operation failed", func(application *app.App) error {
+			return application.RemoveAlias(args[0])
+		})
+	},
+}
+
+// aliasesCmd represents the aliases command
+var aliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "List resource aliases",
+	Long:  `Displays the aliases defined in the database and in the config file.`,
+	Example: `  # List aliases
+  sdm-ui aliases`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Aliases operation failed", func(application *app.App) error {
+			return application.Aliases(os.Stdout, true)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	rootCmd.AddCommand(unaliasCmd)
+	rootCmd.AddCommand(aliasesCmd)
+}
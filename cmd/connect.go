@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// connectCmd represents the connect command
+var connectCmd = &cobra.Command{
+	Use:   "connect [name|alias|pattern...]",
+	Short: "Connect to SDM resources",
+	Long: `Connects to SDM resources without opening a menu. Resources can be selected by
+name, alias or glob pattern, narrowed by the tag filters. Without a name, every
+resource matching the --tag flags is connected, regardless of the configured
+tagFilters. A summary line is printed for every resource and the command exits
+with a non-zero status if any connection fails.`,
+	Example: `  # Connect to a resource by name or alias
+  sdm-ui connect pay-ro
+
+  # Connect to every staging cache
+  sdm-ui connect 'cache-*' --tag env=staging
+
+  # Connect to everything owned by the payments team
+  sdm-ui connect --tag team=payments`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Checked once the config is loaded, only --tag selects resources without a name
+		if len(args) == 0 && len(tagFlags) == 0 {
+			return fmt.Errorf("requires a resource name, pattern or --tag")
+		}
+
+		// Create application instance
+		application, err := app.NewApp(
+			app.WithAccount(confData.Email),
+			app.WithVerbose(confData.Verbose),
+			app.WithDbPath(confData.DBPath),
+			app.WithAliases(confData.Aliases),
+			app.WithBlacklist(confData.BlacklistPatterns),
+			app.WithFilterRules(confData.FilterRules),
+			app.WithTagFilters(tagFilters()),
+			app.WithCommand(app.DMenuCommandNoop),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
+			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to initialize application")
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Ensure proper resource cleanup
+		defer func() {
+			if err := application.Close(); err != nil {
+				log.Warn().Err(err).Msg("Error while closing application resources")
+			}
+		}()
+
+		// Run connect command with error handling
+		if err := application.Connect(os.Stdout, args, tagFlags); err != nil {
+			log.Error().Err(err).Msg("Connect operation failed")
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(connectCmd)
+	addTagFlag(connectCmd)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/marianozunino/sdm-ui/internal/daemon"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var daemonInterval time.Duration
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the cache up to date in the background",
+	Long: `Refreshes the SDM resources in the background and serves them over a Unix socket in
+$XDG_RUNTIME_DIR. While it runs, dmenu, fzf and list read the resources and connect through
+it instead of waiting for the SDM CLI.
+
+The daemon can't ask for a password: when the session expires, the next command run from a
+menu logs in again. It never logs out either: while another account is logged in, it stops
+refreshing the resources of its own.`,
+	Example: `  # Refresh the resources every 30 seconds
+  sdm-ui daemon --interval 30s`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		runAppCommand("Daemon failed", func(application *app.App) error {
+			path, err := daemon.SocketPath(confData.Email)
+			if err != nil {
+				return err
+			}
+
+			listener, err := daemon.Listen(path)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(path)
+
+			server := daemon.NewServer(confData.Email, application.DaemonBackend(), daemon.WithInterval(daemonInterval))
+			return server.Serve(ctx, listener)
+		}, app.WithContext(ctx), app.WithDaemonBackend())
+	},
+}
+
+// daemonStatusCmd represents the daemon status command
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the daemon",
+	Long:  `Displays whether the daemon of the account is running and when it last refreshed the resources.`,
+	Example: `  # Check the daemon
+  sdm-ui daemon status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := daemon.SocketPath(confData.Email)
+		if err == nil {
+			var status daemon.Status
+			status, err = daemon.NewClient(path).Status(context.Background())
+			if err == nil {
+				fmt.Printf("Daemon running on %s\n", path)
+				fmt.Printf("Account:   %s\n", status.Account)
+				fmt.Printf("Resources: %d\n", status.Resources)
+				if !status.SyncedAt.IsZero() {
+					fmt.Printf("Synced:    %s (%s ago)\n", status.SyncedAt.Format(time.DateTime), time.Since(status.SyncedAt).Round(time.Second))
+				}
+				if status.SyncError != "" {
+					fmt.Printf("Error:     %s\n", status.SyncError)
+				}
+				return
+			}
+		}
+
+		log.Debug().Err(err).Msg("Daemon status failed")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", daemon.DefaultInterval, "how often the resources are refreshed")
+}
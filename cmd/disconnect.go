@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var disconnectAll bool
+
+// disconnectCmd represents the disconnect command
+var disconnectCmd = &cobra.Command{
+	Use:   "disconnect [name]",
+	Short: "Disconnect from SDM resources",
+	Long:  `Disconnects from a single SDM resource by name, or from every connected resource with --all.`,
+	Example: `  # Disconnect from a single resource
+  sdm-ui disconnect my-database
+
+  # Disconnect from all resources
+  sdm-ui disconnect --all`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if disconnectAll && len(args) > 0 {
+			return errors.New("cannot use a resource name together with --all")
+		}
+		if !disconnectAll && len(args) != 1 {
+			return errors.New("requires a resource name or --all")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Create application instance
+		application, err := app.NewApp(
+			app.WithAccount(confData.Email),
+			app.WithVerbose(confData.Verbose),
+			app.WithDbPath(confData.DBPath),
+			app.WithAliases(confData.Aliases),
+			app.WithCommand(app.DMenuCommandNoop),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
+			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to initialize application")
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Ensure proper resource cleanup
+		defer func() {
+			if err := application.Close(); err != nil {
+				log.Warn().Err(err).Msg("Error while closing application resources")
+			}
+		}()
+
+		// Run disconnect command with error handling
+		if disconnectAll {
+			err = application.DisconnectAll()
+		} else {
+			err = application.Disconnect(args[0])
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Disconnect operation failed")
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(disconnectCmd)
+
+	disconnectCmd.Flags().BoolVarP(&disconnectAll, "all", "a", false, "disconnect from all resources")
+}
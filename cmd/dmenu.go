@@ -22,8 +22,10 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/marianozunino/sdm-ui/internal/app"
@@ -32,40 +34,35 @@ import (
 )
 
 var (
-	useWofi bool
-	useRofi bool
+	useWofi         bool
+	useRofi         bool
+	dmenuDisconnect bool
+	dmenuAccounts   bool
 )
 
 // dmenuCmd represents the dmenu command
 var dmenuCmd = &cobra.Command{
 	Use:   "dmenu",
 	Short: "Opens dmenu with available data sources",
-	Long:  `Displays a menu of available SDM data sources using either rofi or wofi and allows selecting one to connect.`,
+	Long:  `Displays a menu of available SDM data sources using rofi, wofi or another dmenu-like launcher and allows selecting one to connect.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Determine which menu command to use
-		var commandOption app.AppOption
-		if useWofi {
-			commandOption = app.WithCommand(app.DMenuCommandWofi)
-			log.Debug().Msg("Using wofi as menu command")
-		} else {
-			commandOption = app.WithCommand(app.DMenuCommandRofi)
-			log.Debug().Msg("Using rofi as menu command")
+		// Pick the account first, the menu then lists its resources
+		var account app.Account
+		if dmenuAccounts {
+			var switched bool
+			var err error
+			account, switched, err = pickAccount(cmd)
+			if err != nil {
+				log.Error().Err(err).Msg("Account switch failed")
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if !switched {
+				return
+			}
 		}
 
-		// Create application instance
-		application, err := app.NewApp(
-			app.WithAccount(confData.Email),
-			app.WithVerbose(confData.Verbose),
-			app.WithDbPath(confData.DBPath),
-			app.WithBlacklist(confData.BlacklistPatterns),
-			commandOption,
-			app.WithTimeout(30*time.Second),
-		)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to initialize application")
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
+		application := newDMenuApp()
 
 		// Ensure proper resource cleanup
 		defer func() {
@@ -74,6 +71,17 @@ var dmenuCmd = &cobra.Command{
 			}
 		}()
 
+		if dmenuAccounts {
+			if err := application.SwitchAccount(); err != nil {
+				log.Error().Err(err).Msg("Account switch failed")
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := saveActiveProfile(account.Profile); err != nil {
+				log.Warn().Err(err).Msg("Could not keep the selected account for the next commands")
+			}
+		}
+
 		// Run dmenu command with error handling
 		if err := application.DMenu(); err != nil {
 			log.Error().Err(err).Msg("DMenu operation failed")
@@ -83,20 +91,105 @@ var dmenuCmd = &cobra.Command{
 	},
 }
 
+// newDMenuApp creates the application of the dmenu command, exiting when it can't be created
+func newDMenuApp() *app.App {
+	// Determine which launcher to use, --wofi and --rofi being shortcuts for --launcher
+	launcher := app.DMenuCommand(confData.Launcher)
+	if useWofi {
+		launcher = app.DMenuCommandWofi
+	} else if useRofi {
+		launcher = app.DMenuCommandRofi
+	}
+	log.Debug().Str("launcher", launcher.String()).Msg("Using launcher as menu command")
+
+	// Create application instance
+	application, err := app.NewApp(
+		app.WithAccount(confData.Email),
+		app.WithVerbose(confData.Verbose),
+		app.WithDbPath(confData.DBPath),
+		app.WithAliases(confData.Aliases),
+		app.WithBlacklist(confData.BlacklistPatterns),
+		app.WithFilterRules(confData.FilterRules),
+		app.WithTagFilters(tagFilters()),
+		app.WithSortMode(app.SortMode(confData.Sort)),
+		app.WithCommand(launcher),
+		app.WithPasswordCommand(passwordCommand(app.PasswordCommandZenity)),
+		app.WithLauncherPreference(confData.LauncherPrefs),
+		app.WithIcons(confData.Icons),
+		app.WithKeybindings(confData.Keybindings),
+		app.WithActionMenu(confData.ActionMenu),
+		app.WithSelectionAction(selectionAction(dmenuDisconnect)),
+		app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
+		app.WithTimeout(30*time.Second),
+		app.WithRetry(confData.Retry),
+		app.WithListener(confData.Listener),
+		app.WithCacheTTL(confData.CacheTTL),
+		app.WithDaemon(),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize application")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return application
+}
+
+// pickAccount lets the user pick a configured account in the launcher and applies its profile.
+// It reports false when the menu is dismissed.
+func pickAccount(cmd *cobra.Command) (app.Account, bool, error) {
+	application := newDMenuApp()
+	account, err := application.PickAccount(configuredAccounts())
+	if closeErr := application.Close(); closeErr != nil {
+		log.Warn().Err(closeErr).Msg("Error while closing application resources")
+	}
+	if errors.Is(err, app.ErrNoSelection) {
+		return app.Account{}, false, nil
+	}
+	if err != nil {
+		return app.Account{}, false, err
+	}
+
+	// Start over from the top-level settings so nothing of the previous profile remains
+	confData = baseConfig
+	if err := applyProfile(cmd, account.Profile); err != nil {
+		return app.Account{}, false, err
+	}
+	return account, true, nil
+}
+
 func init() {
 	rootCmd.AddCommand(dmenuCmd)
+	addTagFlag(dmenuCmd)
+	addSortFlag(dmenuCmd)
 
 	// Add menu selection flags
 	dmenuCmd.Flags().BoolVarP(&useWofi, "wofi", "w", false, "use wofi as dmenu")
-	dmenuCmd.Flags().BoolVarP(&useRofi, "rofi", "r", true, "use rofi as dmenu")
+	dmenuCmd.Flags().BoolVarP(&useRofi, "rofi", "r", false, "use rofi as dmenu")
+	dmenuCmd.Flags().StringVarP(&confData.Launcher, "launcher", "l", app.DMenuCommandAuto.String(), "launcher to use: auto, "+strings.Join(app.Launchers(), ", "))
+	dmenuCmd.Flags().BoolVar(&dmenuDisconnect, "disconnect", false, "disconnect from the selected resource instead of connecting")
+	dmenuCmd.Flags().BoolVar(&confData.ActionMenu, "actions", false, "pick an action for the selected resource from a second menu")
+	dmenuCmd.Flags().BoolVar(&dmenuAccounts, "accounts", false, "pick the account to log in with before listing its resources")
 
 	// Make flags mutually exclusive
 	dmenuCmd.MarkFlagsMutuallyExclusive("wofi", "rofi")
 
 	// Add usage examples to help text
-	dmenuCmd.Example = `  # Use rofi (default)
+	dmenuCmd.Example = `  # Use the best launcher installed for the session (default)
   sdm-ui dmenu
 
   # Use wofi instead
-  sdm-ui dmenu --wofi`
+  sdm-ui dmenu --wofi
+
+  # Use any supported launcher
+  sdm-ui dmenu --launcher fuzzel
+
+  # Pick a connected resource to disconnect from
+  sdm-ui dmenu --disconnect
+
+  # Choose what to do with the selected resource
+  sdm-ui dmenu --actions
+
+  # Switch to another account, then list its resources
+  sdm-ui dmenu --accounts`
 }
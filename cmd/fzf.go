@@ -31,23 +31,38 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var fzfDisconnect bool
+
 // fzfCmd represents the fzf command
 var fzfCmd = &cobra.Command{
 	Use:   "fzf",
 	Short: "Opens fzf with available data sources",
 	Long:  `Displays a fuzzy finder interface with available SDM data sources and allows selecting one to connect.`,
 	Example: `  # List and select SDM resources using fzf
-  sdm-ui fzf`,
+  sdm-ui fzf
+
+  # Pick a connected resource to disconnect from
+  sdm-ui fzf --disconnect`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Create application instance
 		application, err := app.NewApp(
 			app.WithAccount(confData.Email),
 			app.WithVerbose(confData.Verbose),
 			app.WithDbPath(confData.DBPath),
+			app.WithAliases(confData.Aliases),
 			app.WithBlacklist(confData.BlacklistPatterns),
+			app.WithFilterRules(confData.FilterRules),
+			app.WithTagFilters(tagFilters()),
+			app.WithSortMode(app.SortMode(confData.Sort)),
 			app.WithCommand(app.DMenuCommandNoop),
-			app.WithPasswordCommand(app.PasswordCommandCLI),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSelectionAction(selectionAction(fzfDisconnect)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
 			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
+			app.WithCacheTTL(confData.CacheTTL),
+			app.WithDaemon(),
 		)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize application")
@@ -73,4 +88,8 @@ var fzfCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(fzfCmd)
+	addTagFlag(fzfCmd)
+	addSortFlag(fzfCmd)
+
+	fzfCmd.Flags().BoolVar(&fzfDisconnect, "disconnect", false, "disconnect from the selected resource instead of connecting")
 }
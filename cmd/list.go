@@ -31,13 +31,30 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	explainFilter bool
+	maxAge        time.Duration
+)
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List SDM resources",
 	Long:  `Displays all available SDM resources in a formatted table.`,
 	Example: `  # List all SDM resources
-  sdm-ui list`,
+  sdm-ui list
+
+  # List production resources not owned by the payments team
+  sdm-ui list --tag env=prod --tag team!=payments
+
+  # List resources alphabetically
+  sdm-ui list --sort name
+
+  # Sync first when the cache is older than 10 minutes
+  sdm-ui list --max-age 10m
+
+  # Show which filter rule hides each resource
+  sdm-ui list --explain-filter`,
 	Aliases: []string{"ls"},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Create application instance
@@ -45,10 +62,20 @@ var listCmd = &cobra.Command{
 			app.WithAccount(confData.Email),
 			app.WithVerbose(confData.Verbose),
 			app.WithDbPath(confData.DBPath),
+			app.WithAliases(confData.Aliases),
 			app.WithBlacklist(confData.BlacklistPatterns),
+			app.WithFilterRules(confData.FilterRules),
+			app.WithTagFilters(tagFilters()),
+			app.WithSortMode(app.SortMode(confData.Sort)),
 			app.WithCommand(app.DMenuCommandNoop),
-			app.WithPasswordCommand(app.PasswordCommandCLI),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
 			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
+			app.WithCacheTTL(confData.CacheTTL),
+			app.WithMaxAge(maxAge),
+			app.WithDaemon(),
 		)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize application")
@@ -64,7 +91,12 @@ var listCmd = &cobra.Command{
 		}()
 
 		// Run list command with error handling
-		if err := application.List(os.Stdout, true); err != nil {
+		if explainFilter {
+			err = application.ExplainFilter(os.Stdout)
+		} else {
+			err = application.List(os.Stdout, true)
+		}
+		if err != nil {
 			log.Error().Err(err).Msg("List operation failed")
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -74,4 +106,9 @@ var listCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	addTagFlag(listCmd)
+	addSortFlag(listCmd)
+
+	listCmd.Flags().BoolVar(&explainFilter, "explain-filter", false, "show every resource with the filter rule that shows or hides it")
+	listCmd.Flags().DurationVar(&maxAge, "max-age", 0, "sync first when the cache is older than this duration, e.g. 10m")
 }
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin <name>",
+	Short: "Pin an SDM resource",
+	Long:  `Pins an SDM resource so it is always listed first in list, fzf and dmenu.`,
+	Example: `  # Pin a resource
+  sdm-ui pin my-database`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Pin operation failed", func(application *app.App) error {
+			return application.Pin(args[0])
+		})
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <name>",
+	Short: "Unpin an SDM resource",
+	Long:  `Removes the pin from an SDM resource so it is sorted like any other resource.`,
+	Example: `  # Unpin a resource
+  sdm-ui unpin my-database`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Unpin operation failed", func(application *app.App) error {
+			return application.Unpin(args[0])
+		})
+	},
+}
+
+// pinsCmd represents the pins command
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "List pinned SDM resources",
+	Long:  `Displays the pinned SDM resources in a formatted table.`,
+	Example: `  # List pinned resources
+  sdm-ui pins`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Pins operation failed", func(application *app.App) error {
+			return application.Pins(os.Stdout, true)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+	rootCmd.AddCommand(pinsCmd)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2025 Mariano Zunino <marianoz@posteo.net>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adrg/xdg"
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/marianozunino/sdm-ui/internal/libsecret"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// activeProfileFile keeps the profile picked by `sdm-ui profile switch`
+const activeProfileFile = "sdm-ui/profile"
+
+// profile holds the settings of a named profile, overriding the top-level ones it sets
+type profile struct {
+	Email             string            `mapstructure:"email"`
+	DBPath            string            `mapstructure:"dbPath"`
+	BlacklistPatterns []string          `mapstructure:"blacklistPatterns"`
+	Launcher          string            `mapstructure:"launcher"`
+	SecretProvider    string            `mapstructure:"secretProvider"`
+	SecretOptions     libsecret.Options `mapstructure:"secretProviderOptions"`
+}
+
+var (
+	// profileFlag holds the --profile flag
+	profileFlag string
+
+	// baseConfig is the configuration before a profile was applied
+	baseConfig config
+
+	// explicitFlags holds the flags given on the command line, which profiles don't override
+	explicitFlags map[string]bool
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage account profiles",
+	Long: `Profiles group the settings of one StrongDM account under a name in the config file.
+The active profile is picked with --profile, then with "sdm-ui profile switch", then
+with the profile setting.`,
+}
+
+// profileSwitchCmd represents the profile switch command
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch <profile>",
+	Short: "Log in with the account of a profile and make it the active one",
+	Long: `Logs out the current StrongDM account, logs in with the account of the given profile
+and uses that profile for the next commands.`,
+	Example: `  # Work on production
+  sdm-ui profile switch prod`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Replace the active profile before the required email is checked
+		confData = baseConfig
+		return applyProfile(cmd, args[0])
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		runAppCommand("Profile switch failed", func(application *app.App) error {
+			if err := application.SwitchAccount(); err != nil {
+				return err
+			}
+			return saveActiveProfile(args[0])
+		})
+		fmt.Printf("Switched to profile %s (%s)\n", args[0], confData.Email)
+	},
+}
+
+// profileListCmd represents the profile list command
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured profiles",
+	Long:  `Displays the profiles of the config file, the active one being marked with *.`,
+	Example: `  # List profiles
+  sdm-ui profile list`,
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		active := activeProfile()
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", "", "PROFILE", "EMAIL")
+		for _, name := range slices.Sorted(maps.Keys(confData.Profiles)) {
+			marker := ""
+			if name == active {
+				marker = "*"
+			}
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", marker, name, confData.Profiles[name].Email)
+		}
+		tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileSwitchCmd)
+	profileCmd.AddCommand(profileListCmd)
+}
+
+// recordExplicitFlags remembers the flags given on the command line
+func recordExplicitFlags(cmd *cobra.Command) {
+	explicitFlags = map[string]bool{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		explicitFlags[f.Name] = true
+	})
+}
+
+// activeProfile returns the name of the profile to use, or an empty string for the top-level settings
+func activeProfile() string {
+	if profileFlag != "" {
+		return profileFlag
+	}
+
+	if path, err := xdg.SearchStateFile(activeProfileFile); err == nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Could not read the active profile")
+		} else if name := strings.TrimSpace(string(data)); name != "" {
+			if _, ok := confData.Profiles[name]; ok {
+				return name
+			}
+			log.Warn().Str("profile", name).Msg("The active profile is no longer configured, ignoring it")
+		}
+	}
+
+	return viper.GetString("profile")
+}
+
+// saveActiveProfile makes the profile the one used when --profile isn't given
+func saveActiveProfile(name string) error {
+	path, err := xdg.StateFile(activeProfileFile)
+	if err != nil {
+		return fmt.Errorf("could not save the active profile: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0o600); err != nil {
+		return fmt.Errorf("could not save the active profile: %w", err)
+	}
+	return nil
+}
+
+// configuredAccounts returns the account of the top-level settings, unless a profile replaces it
+// by default, followed by the account of every profile
+func configuredAccounts() []app.Account {
+	accounts := make([]app.Account, 0, len(baseConfig.Profiles)+1)
+	if baseConfig.Email != "" && viper.GetString("profile") == "" {
+		accounts = append(accounts, app.Account{Email: baseConfig.Email})
+	}
+	for _, name := range slices.Sorted(maps.Keys(baseConfig.Profiles)) {
+		accounts = append(accounts, app.Account{Profile: name, Email: baseConfig.Profiles[name].Email})
+	}
+	return accounts
+}
+
+// applyProfile overrides the top-level settings with the ones of the profile, except for explicit flags
+func applyProfile(cmd *cobra.Command, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	p, ok := confData.Profiles[name]
+	if !ok {
+		if len(confData.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles configured", name)
+		}
+		return fmt.Errorf("unknown profile %q, available: %s", name, strings.Join(slices.Sorted(maps.Keys(confData.Profiles)), ", "))
+	}
+
+	if p.Email == "" {
+		return fmt.Errorf("profile %q has no email", name)
+	}
+	if !explicitFlags["email"] {
+		if err := cmd.Flags().Set("email", p.Email); err != nil {
+			return err
+		}
+	}
+	if p.DBPath != "" && !explicitFlags["db"] {
+		confData.DBPath = p.DBPath
+	}
+	if p.BlacklistPatterns != nil {
+		confData.BlacklistPatterns = p.BlacklistPatterns
+	}
+	if p.Launcher != "" && !explicitFlags["launcher"] {
+		confData.Launcher = p.Launcher
+	}
+	if p.SecretProvider != "" {
+		confData.SecretProvider = p.SecretProvider
+		confData.SecretOptions = p.SecretOptions
+	}
+
+	return nil
+}
@@ -25,8 +25,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/marianozunino/sdm-ui/internal/app"
+	"github.com/marianozunino/sdm-ui/internal/filter"
+	"github.com/marianozunino/sdm-ui/internal/libsecret"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -34,10 +41,26 @@ import (
 
 // Configuration structure
 type config struct {
-	Email             string   `mapstructure:"email"`
-	DBPath            string   `mapstructure:"dbPath"`
-	Verbose           bool     `mapstructure:"verbose"`
-	BlacklistPatterns []string `mapstructure:"blacklistPatterns"`
+	Email             string              `mapstructure:"email"`
+	DBPath            string              `mapstructure:"dbPath"`
+	Verbose           bool                `mapstructure:"verbose"`
+	BlacklistPatterns []string            `mapstructure:"blacklistPatterns"`
+	FilterRules       []filter.RuleConfig `mapstructure:"filterRules"`
+	TagFilters        []string            `mapstructure:"tagFilters"`
+	Sort              string              `mapstructure:"sort"`
+	Aliases           map[string]string   `mapstructure:"aliases"`
+	Keybindings       []app.Keybinding    `mapstructure:"keybindings"`
+	ActionMenu        bool                `mapstructure:"actionMenu"`
+	Launcher          string              `mapstructure:"launcher"`
+	LauncherPrefs     []string            `mapstructure:"launcherPreference"`
+	Icons             map[string]string   `mapstructure:"icons"`
+	SecretProvider    string              `mapstructure:"secretProvider"`
+	SecretOptions     libsecret.Options   `mapstructure:"secretProviderOptions"`
+	PasswordCommand   string              `mapstructure:"passwordCommand"`
+	Retry             sdm.RetryConfig     `mapstructure:"retry"`
+	Listener          app.ListenerConfig  `mapstructure:"listener"`
+	CacheTTL          time.Duration       `mapstructure:"cacheTTL"`
+	Profiles          map[string]profile  `mapstructure:"profiles"`
 }
 
 // Global configuration instance
@@ -48,7 +71,19 @@ var (
 		DBPath:            xdg.DataHome,
 		Verbose:           false,
 		BlacklistPatterns: []string{},
+		FilterRules:       []filter.RuleConfig{},
+		TagFilters:        []string{},
+		Sort:              app.SortFrecency.String(),
+		Aliases:           map[string]string{},
+		Launcher:          app.DMenuCommandAuto.String(),
+		LauncherPrefs:     []string{},
+		Icons:             map[string]string{},
+		SecretProvider:    libsecret.ProviderKeyring,
+		CacheTTL:          app.DefaultCacheTTL,
 	}
+
+	// tagFlags holds the --tag filters given on the command line
+	tagFlags []string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -84,6 +119,13 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&confData.Email, "email", "e", "", "email address")
 	rootCmd.PersistentFlags().BoolVarP(&confData.Verbose, "verbose", "v", false, "enable verbose output")
 	rootCmd.PersistentFlags().StringVarP(&confData.DBPath, "db", "d", xdg.DataHome, "database path")
+	rootCmd.PersistentFlags().StringVarP(&profileFlag, "profile", "p", "", "profile of the config file to use")
+
+	passwordCommands := make([]string, len(app.PasswordCommands))
+	for i, pc := range app.PasswordCommands {
+		passwordCommands[i] = pc.String()
+	}
+	rootCmd.PersistentFlags().StringVar(&confData.PasswordCommand, "password-command", "", "password prompt: "+strings.Join(passwordCommands, ", ")+" (default zenity for dmenu, cli otherwise)")
 
 	rootCmd.MarkPersistentFlagRequired("email")
 }
@@ -106,6 +148,8 @@ func loadConfig(cmd *cobra.Command) error {
 		}
 	}
 
+	recordExplicitFlags(cmd)
+
 	cmd.Flags().Visit(func(f *pflag.Flag) {
 		viper.Set(f.Name, f.Value.String())
 	})
@@ -118,6 +162,125 @@ func loadConfig(cmd *cobra.Command) error {
 	})
 
 	confData.BlacklistPatterns = viper.GetStringSlice("blacklistPatterns")
+	confData.TagFilters = viper.GetStringSlice("tagFilters")
+	confData.Aliases = viper.GetStringMapString("aliases")
+	confData.LauncherPrefs = viper.GetStringSlice("launcherPreference")
+	confData.Icons = viper.GetStringMapString("icons")
+	if viper.IsSet("secretProvider") {
+		confData.SecretProvider = viper.GetString("secretProvider")
+	}
+
+	if viper.IsSet("cacheTTL") {
+		confData.CacheTTL = viper.GetDuration("cacheTTL")
+	}
+
+	if f := cmd.Flags().Lookup("password-command"); f == nil || !f.Changed {
+		confData.PasswordCommand = viper.GetString("passwordCommand")
+	}
+
+	if f := cmd.Flags().Lookup("actions"); f == nil || !f.Changed {
+		confData.ActionMenu = viper.GetBool("actionMenu")
+	}
+
+	if err := viper.UnmarshalKey("filterRules", &confData.FilterRules); err != nil {
+		return fmt.Errorf("could not read filterRules: %w", err)
+	}
+
+	if err := viper.UnmarshalKey("keybindings", &confData.Keybindings); err != nil {
+		return fmt.Errorf("could not read keybindings: %w", err)
+	}
+
+	if err := viper.UnmarshalKey("secretProviderOptions", &confData.SecretOptions); err != nil {
+		return fmt.Errorf("could not read secretProviderOptions: %w", err)
+	}
+
+	if err := viper.UnmarshalKey("retry", &confData.Retry); err != nil {
+		return fmt.Errorf("could not read retry: %w", err)
+	}
+
+	if err := viper.UnmarshalKey("listener", &confData.Listener); err != nil {
+		return fmt.Errorf("could not read listener: %w", err)
+	}
+
+	if err := viper.UnmarshalKey("profiles", &confData.Profiles); err != nil {
+		return fmt.Errorf("could not read profiles: %w", err)
+	}
 
-	return nil
+	baseConfig = confData
+	return applyProfile(cmd, activeProfile())
+}
+
+// addTagFlag registers the --tag filter flag on a command
+func addTagFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "filter resources by key=value or key!=value (repeatable)")
+}
+
+// tagFilters returns the configured tag filters followed by the ones given as flags
+func tagFilters() []string {
+	filters := make([]string, 0, len(confData.TagFilters)+len(tagFlags))
+	filters = append(filters, confData.TagFilters...)
+	return append(filters, tagFlags...)
+}
+
+// addSortFlag registers the --sort flag on a command
+func addSortFlag(cmd *cobra.Command) {
+	modes := make([]string, len(app.SortModes))
+	for i, mode := range app.SortModes {
+		modes[i] = mode.String()
+	}
+	cmd.Flags().StringVar(&confData.Sort, "sort", app.SortFrecency.String(), "sort order: "+strings.Join(modes, ", "))
+}
+
+// passwordCommand returns the configured password prompt, or the default of the subcommand when unset
+func passwordCommand(fallback app.PasswordCommand) app.PasswordCommand {
+	if confData.PasswordCommand != "" {
+		return app.PasswordCommand(confData.PasswordCommand)
+	}
+	return fallback
+}
+
+// selectionAction maps the --disconnect menu flag to the matching selection action
+func selectionAction(disconnect bool) app.SelectionAction {
+	if disconnect {
+		return app.SelectionActionDisconnect
+	}
+	return app.SelectionActionConnect
+}
+
+// runAppCommand creates a non-interactive application and runs the given operation with it.
+// The extra options are applied after the common ones.
+func runAppCommand(failureMsg string, run func(*app.App) error, opts ...app.AppOption) {
+	// Create application instance
+	options := []app.AppOption{
+		app.WithAccount(confData.Email),
+		app.WithVerbose(confData.Verbose),
+		app.WithDbPath(confData.DBPath),
+		app.WithAliases(confData.Aliases),
+		app.WithCommand(app.DMenuCommandNoop),
+		app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+		app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
+		app.WithTimeout(30 * time.Second),
+		app.WithRetry(confData.Retry),
+		app.WithListener(confData.Listener),
+		app.WithCacheTTL(confData.CacheTTL),
+	}
+	application, err := app.NewApp(append(options, opts...)...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize application")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Ensure proper resource cleanup
+	defer func() {
+		if err := application.Close(); err != nil {
+			log.Warn().Err(err).Msg("Error while closing application resources")
+		}
+	}()
+
+	if err := run(application); err != nil {
+		log.Error().Err(err).Msg(failureMsg)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -31,11 +31,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var syncDiffFlag bool
+
 // syncCmd represents the sync command
 var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Synchronizes the internal cache",
-	Long:  `Fetches the latest data from SDM and updates the local cache database`,
+	Long: `Fetches the latest data from SDM and updates the local cache database.
+Resources no longer listed by SDM are removed, and the number of added,
+removed and changed resources is reported.`,
+	Example: `  # Sync the cache
+  sdm-ui sync
+
+  # Sync and show which resources were added (+), removed (-) or changed (~)
+  sdm-ui sync --diff`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Create application with options
 		application, err := app.NewApp(
@@ -43,9 +52,13 @@ var syncCmd = &cobra.Command{
 			app.WithVerbose(confData.Verbose),
 			app.WithDbPath(confData.DBPath),
 			app.WithBlacklist(confData.BlacklistPatterns),
+			app.WithFilterRules(confData.FilterRules),
 			app.WithCommand(app.DMenuCommandNoop),
-			app.WithPasswordCommand(app.PasswordCommandCLI),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
 			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
 		)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize application")
@@ -61,7 +74,7 @@ var syncCmd = &cobra.Command{
 		}()
 
 		// Run synchronization
-		if err := application.Sync(); err != nil {
+		if err := application.SyncReport(os.Stdout, syncDiffFlag); err != nil {
 			log.Error().Err(err).Msg("Synchronization failed")
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -71,4 +84,6 @@ var syncCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().BoolVar(&syncDiffFlag, "diff", false, "print the resources added, removed or changed")
 }
@@ -45,8 +45,11 @@ var wipeCmd = &cobra.Command{
 			app.WithVerbose(confData.Verbose),
 			app.WithDbPath(confData.DBPath),
 			app.WithCommand(app.DMenuCommandNoop),
-			app.WithPasswordCommand(app.PasswordCommandCLI),
+			app.WithPasswordCommand(passwordCommand(app.PasswordCommandCLI)),
+			app.WithSecretProvider(confData.SecretProvider, confData.SecretOptions),
 			app.WithTimeout(30*time.Second),
+			app.WithRetry(confData.Retry),
+			app.WithListener(confData.Listener),
 		)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize application")
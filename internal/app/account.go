@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/rs/zerolog/log"
+)
+
+// Account is a StrongDM account that can be picked in the account switcher
+type Account struct {
+	Profile string // Name of the profile holding the account, empty for the top-level settings
+	Email   string
+}
+
+// label returns the name shown for the account
+func (a Account) label() string {
+	if a.Profile == "" {
+		return "default"
+	}
+	return a.Profile
+}
+
+// PickAccount shows the accounts in the launcher, the logged in one being marked, and returns
+// the selected one. It returns ErrNoSelection when the menu is dismissed.
+func (p *App) PickAccount(accounts []Account) (Account, error) {
+	current := ""
+
+	ctx, cancel := context.WithTimeout(p.context, p.timeout)
+	status, err := p.sdmWrapper.ReadyWithContext(ctx)
+	cancel()
+	if err != nil {
+		// The menu is still useful without the mark, e.g. while the listener is stopped
+		log.Warn().Err(err).Msg("Could not tell which account is logged in")
+	} else if status.Account != nil {
+		current = *status.Account
+	}
+
+	entries, opts := p.accountRows(accounts, current)
+
+	idx, _, err := p.menuSelect("Account", entries, opts)
+	if err != nil {
+		return Account{}, err
+	}
+
+	account := accounts[idx]
+	log.Debug().
+		Str("profile", account.Profile).
+		Str("email", account.Email).
+		Msg("Account selected")
+	return account, nil
+}
+
+// accountRows renders the accounts as menu entries, marking the one logged in.
+// rofi also highlights it, the other launchers ignore the active rows.
+func (p *App) accountRows(accounts []Account, current string) ([]*entry.Entry, menuOptions) {
+	var opts menuOptions
+
+	buf := new(bytes.Buffer)
+	tw := tabwriter.NewWriter(buf, 0, 8, 2, '\t', 0)
+	for i, account := range accounts {
+		mark := " "
+		if account.Email == current {
+			mark = "✔"
+			opts.Active = append(opts.Active, i)
+		}
+		fmt.Fprintf(tw, "%v %v\t%v\n", mark, account.label(), account.Email)
+	}
+	tw.Flush()
+
+	return p.createEntriesFromBuffer(buf), opts
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// SelectionAction represents what happens to the data source picked in a menu
+type SelectionAction string
+
+// Available selection actions
+const (
+	SelectionActionConnect    SelectionAction = "connect"
+	SelectionActionDisconnect SelectionAction = "disconnect"
+)
+
+// String returns the string representation of the selection action
+func (a SelectionAction) String() string {
+	return string(a)
+}
+
+// prompt returns the menu prompt matching the selection action
+func (a SelectionAction) prompt() string {
+	if a == SelectionActionDisconnect {
+		return "Disconnect Data Source"
+	}
+	return "Select Data Source"
+}
+
+// menuDataSources returns the data sources that make sense for the current selection action
+func (p *App) menuDataSources() ([]storage.DataSource, error) {
+	dataSources, err := p.GetSortedDataSources()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.selectionAction != SelectionActionDisconnect {
+		return dataSources, nil
+	}
+
+	// Only connected data sources can be disconnected
+	connected := make([]storage.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		if ds.Status == "connected" {
+			connected = append(connected, ds)
+		}
+	}
+
+	log.Debug().Int("count", len(connected)).Msg("Filtered connected data sources")
+	return connected, nil
+}
+
+// applySelectionAction runs the configured selection action on the data source
+func (p *App) applySelectionAction(ds storage.DataSource) error {
+	switch p.selectionAction {
+	case SelectionActionConnect:
+		return p.connectDataSource(ds)
+	case SelectionActionDisconnect:
+		return p.disconnectDataSource(ds)
+	default:
+		return fmt.Errorf("unknown selection action: %s", p.selectionAction)
+	}
+}
+
+// applySelectionActionToAll runs the configured selection action on every selected data source.
+// Connections are established in parallel, and both actions are reported in a single
+// notification followed by a single sync.
+func (p *App) applySelectionActionToAll(dataSources []storage.DataSource) error {
+	if len(dataSources) == 1 {
+		return p.applySelectionAction(dataSources[0])
+	}
+
+	switch p.selectionAction {
+	case SelectionActionConnect:
+		return p.connectDataSources(dataSources)
+	case SelectionActionDisconnect:
+		return p.disconnectDataSources(dataSources)
+	default:
+		return fmt.Errorf("unknown selection action: %s", p.selectionAction)
+	}
+}
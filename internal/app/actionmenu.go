@@ -0,0 +1,207 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// menuAction is an entry of the secondary menu shown after picking a data source
+type menuAction struct {
+	Label string
+	Run   func(ds storage.DataSource) error
+}
+
+// terminalFallbacks are tried in order when $TERMINAL is not set
+var terminalFallbacks = []string{"x-terminal-emulator", "alacritty", "foot", "kitty", "xterm"}
+
+// menuActions returns the actions that apply to the data source given its type and status
+func (p *App) menuActions(ds storage.DataSource) []menuAction {
+	actions := make([]menuAction, 0, 8)
+
+	if ds.Status == "connected" {
+		actions = append(actions, menuAction{"🔌 Disconnect", p.disconnectDataSource})
+	} else {
+		actions = append(actions, menuAction{"⚡ Connect", p.connectDataSource})
+	}
+
+	if ds.Address != "" && !isWebAddress(ds.Address) {
+		actions = append(actions, menuAction{"📋 Copy address", func(ds storage.DataSource) error {
+			return p.copyWithNotification("Address", ds.Address)
+		}})
+	}
+
+	if host, port, ok := hostPort(ds.Address); ok {
+		actions = append(actions,
+			menuAction{"📋 Copy host", func(storage.DataSource) error {
+				return p.copyWithNotification("Host", host)
+			}},
+			menuAction{"📋 Copy port", func(storage.DataSource) error {
+				return p.copyWithNotification("Port", port)
+			}},
+		)
+	}
+
+	if url := webURL(ds); url != "" {
+		actions = append(actions, menuAction{"🌐 Open in browser", func(storage.DataSource) error {
+			openInBrowser(url)
+			return nil
+		}})
+	}
+
+	if client, _, ok := clientCommand(ds); ok {
+		if _, err := exec.LookPath(client); err == nil {
+			actions = append(actions, menuAction{"🖥 Launch " + client, p.launchClient})
+		}
+	}
+
+	if len(ds.Tags) > 0 {
+		actions = append(actions, menuAction{"🏷 Show tags", p.showTags})
+	}
+
+	return actions
+}
+
+// showActionMenu lets the user pick an action for the data source and runs it.
+// It returns ErrNoSelection when the menu is dismissed.
+func (p *App) showActionMenu(ds storage.DataSource) error {
+	actions := p.menuActions(ds)
+
+	entries := make([]*entry.Entry, 0, len(actions))
+	for _, action := range actions {
+		entries = append(entries, entry.New(action.Label))
+	}
+
+	idx, _, err := p.menuSelect(displayName(ds), entries, menuOptions{})
+	if err != nil {
+		return err
+	}
+
+	action := actions[idx]
+	log.Debug().
+		Str("action", action.Label).
+		Str("datasource", ds.Name).
+		Msg("Running menu action")
+	return action.Run(ds)
+}
+
+// showTags lists the tags of the data source in the menu until it is dismissed
+func (p *App) showTags(ds storage.DataSource) error {
+	keys := make([]string, 0, len(ds.Tags))
+	for key := range ds.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]*entry.Entry, 0, len(keys))
+	for _, key := range keys {
+		tag := key
+		if value := ds.Tags[key]; value != "" {
+			tag += "=" + value
+		}
+		entries = append(entries, entry.New(tag))
+	}
+
+	if _, _, err := p.menuSelect(displayName(ds)+" tags", entries, menuOptions{}); err != nil && !errors.Is(err, ErrNoSelection) {
+		return err
+	}
+	return nil
+}
+
+// launchClient connects to the data source if needed and opens its client in a terminal
+func (p *App) launchClient(ds storage.DataSource) error {
+	client, args, ok := clientCommand(ds)
+	if !ok {
+		return fmt.Errorf("no client known for %s resources", ds.Type)
+	}
+
+	terminal, err := terminalCommand()
+	if err != nil {
+		p.notify("🖥 No terminal found", "Set $TERMINAL to launch clients")
+		return err
+	}
+
+	if ds.Status != "connected" {
+		if err := p.connect(ds); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command(terminal, append([]string{"-e", client}, args...)...)
+	log.Debug().
+		Str("terminal", terminal).
+		Strs("args", cmd.Args).
+		Msg("Launching client")
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to launch %s: %w", client, err)
+	}
+
+	// The terminal outlives sdm-ui, don't wait for it
+	return cmd.Process.Release()
+}
+
+// clientCommand returns the command line client for the data source type and its arguments
+func clientCommand(ds storage.DataSource) (string, []string, bool) {
+	host, port, ok := hostPort(ds.Address)
+	if !ok {
+		return "", nil, false
+	}
+
+	switch ResourceType(ds.Type) {
+	case TypePostgres:
+		return "psql", []string{"-h", host, "-p", port}, true
+	case TypeRedis:
+		return "redis-cli", []string{"-h", host, "-p", port}, true
+	case TypeMySQL:
+		return "mysql", []string{"-h", host, "-P", port}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// terminalCommand returns the terminal emulator used to launch clients
+func terminalCommand() (string, error) {
+	if terminal := os.Getenv("TERMINAL"); terminal != "" {
+		return exec.LookPath(terminal)
+	}
+	for _, terminal := range terminalFallbacks {
+		if path, err := exec.LookPath(terminal); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("no terminal emulator found")
+}
+
+// hostPort splits a host:port address, ignoring web URLs
+func hostPort(address string) (string, string, bool) {
+	if isWebAddress(address) {
+		return "", "", false
+	}
+	host, port, err := net.SplitHostPort(address)
+	return host, port, err == nil
+}
+
+// webURL returns the URL to open in a browser for the data source, if any
+func webURL(ds storage.DataSource) string {
+	if ds.WebURL != "" {
+		return ds.WebURL
+	}
+	if isWebAddress(ds.Address) {
+		return ds.Address
+	}
+	return ""
+}
+
+// copyWithNotification copies the value to the clipboard and tells the user what was copied
+func (p *App) copyWithNotification(what, value string) error {
+	copyToClipboard(value)
+	p.notify("📋 "+what+" Copied", value)
+	return nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMenuActions(t *testing.T) {
+	p := &App{}
+
+	tests := []struct {
+		name     string
+		ds       storage.DataSource
+		expected []string
+	}{
+		{
+			name:     "DisconnectedRawTCP",
+			ds:       storage.DataSource{Type: string(TypeRawTCP), Status: "not connected", Address: "localhost:10003"},
+			expected: []string{"⚡ Connect", "📋 Copy address", "📋 Copy host", "📋 Copy port"},
+		},
+		{
+			name:     "ConnectedWithTags",
+			ds:       storage.DataSource{Type: string(TypeRawTCP), Status: "connected", Address: "localhost:10003", Tags: map[string]string{"env": "prod"}},
+			expected: []string{"🔌 Disconnect", "📋 Copy address", "📋 Copy host", "📋 Copy port", "🏷 Show tags"},
+		},
+		{
+			name:     "Website",
+			ds:       storage.DataSource{Type: string(TypeHTTPNoAuth), Status: "not connected", Address: "http://grafana.example.com"},
+			expected: []string{"⚡ Connect", "🌐 Open in browser"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := make([]string, 0)
+			for _, action := range p.menuActions(tt.ds) {
+				labels = append(labels, action.Label)
+			}
+			assert.Equal(t, tt.expected, labels)
+		})
+	}
+}
+
+func TestClientCommand(t *testing.T) {
+	client, args, ok := clientCommand(storage.DataSource{Type: string(TypePostgres), Address: "localhost:10001"})
+	assert.True(t, ok)
+	assert.Equal(t, "psql", client)
+	assert.Equal(t, []string{"-h", "localhost", "-p", "10001"}, args)
+
+	_, _, ok = clientCommand(storage.DataSource{Type: string(TypeHTTPNoAuth), Address: "http://grafana.example.com"})
+	assert.False(t, ok)
+}
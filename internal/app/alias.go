@@ -0,0 +1,177 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrConfigAlias is returned when trying to change an alias declared in the config file
+var ErrConfigAlias = errors.New("alias is declared in the config file")
+
+// SetAlias gives the data source with the given name a short alias
+func (p *App) SetAlias(alias, name string) error {
+	if alias == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if _, ok := p.aliases[alias]; ok {
+		return fmt.Errorf("%w: %s", ErrConfigAlias, alias)
+	}
+
+	ds, err := p.lookupDataSource(name)
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.SetAlias(ds.Name, alias); err != nil {
+		return fmt.Errorf("failed to set alias %s for %s: %w", alias, ds.Name, err)
+	}
+
+	log.Debug().
+		Str("alias", alias).
+		Str("datasource", ds.Name).
+		Msg("Alias set")
+	return nil
+}
+
+// RemoveAlias removes an alias stored in the database
+func (p *App) RemoveAlias(alias string) error {
+	if _, ok := p.aliases[alias]; ok {
+		return fmt.Errorf("%w: %s", ErrConfigAlias, alias)
+	}
+
+	ds, err := p.db.FindByAlias(alias)
+	if errors.Is(err, storage.ErrDataSourceNotFound) {
+		return fmt.Errorf("%w: no data source with alias %s", ErrResourceNotFound, alias)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.SetAlias(ds.Name, ""); err != nil {
+		return fmt.Errorf("failed to remove alias %s: %w", alias, err)
+	}
+
+	log.Debug().
+		Str("alias", alias).
+		Str("datasource", ds.Name).
+		Msg("Alias removed")
+	return nil
+}
+
+// Aliases writes every alias, from the database and the config file, to the provided writer
+func (p *App) Aliases(w io.Writer, withHeader bool) error {
+	dataSources, err := p.cachedDataSources()
+	if err != nil {
+		return err
+	}
+
+	type aliasRow struct{ alias, name, source string }
+	rows := make([]aliasRow, 0, len(p.aliases))
+
+	for alias, name := range p.aliases {
+		rows = append(rows, aliasRow{alias, name, "config"})
+	}
+	for _, ds := range dataSources {
+		if ds.Alias != "" && p.aliases[ds.Alias] == "" {
+			rows = append(rows, aliasRow{ds.Alias, ds.Name, "db"})
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b aliasRow) int {
+		return strings.Compare(a.alias, b.alias)
+	})
+
+	const format = "%v\t%v\t%v\n"
+	tw := tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+	if withHeader {
+		fmt.Fprintf(tw, format, "ALIAS", "NAME", "SOURCE")
+		fmt.Fprintf(tw, format, "-----", "----", "------")
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, format, row.alias, row.name, row.source)
+	}
+	return tw.Flush()
+}
+
+// lookupDataSource finds a cached data source by its name or alias
+func (p *App) lookupDataSource(nameOrAlias string) (storage.DataSource, error) {
+	if nameOrAlias == "" {
+		return storage.DataSource{}, fmt.Errorf("%w: empty data source name", ErrResourceNotFound)
+	}
+
+	name := nameOrAlias
+	if aliased, ok := p.aliases[nameOrAlias]; ok {
+		log.Debug().
+			Str("alias", nameOrAlias).
+			Str("datasource", aliased).
+			Msg("Resolved alias from config")
+		name = aliased
+	}
+
+	ds, err := p.db.GetDatasource(name)
+	if errors.Is(err, storage.ErrDataSourceNotFound) && name == nameOrAlias {
+		ds, err = p.db.FindByAlias(nameOrAlias)
+		if err == nil {
+			log.Debug().
+				Str("alias", nameOrAlias).
+				Str("datasource", ds.Name).
+				Msg("Resolved alias from database")
+		}
+	}
+
+	if errors.Is(err, storage.ErrDataSourceNotFound) {
+		return storage.DataSource{}, fmt.Errorf("%w: %s", ErrResourceNotFound, nameOrAlias)
+	}
+	if err != nil {
+		return storage.DataSource{}, err
+	}
+
+	p.applyConfigAlias(&ds)
+	return ds, nil
+}
+
+// resolveName returns the real name behind an alias, or the input when it isn't one
+func (p *App) resolveName(nameOrAlias string) string {
+	ds, err := p.lookupDataSource(nameOrAlias)
+	if err != nil {
+		return nameOrAlias
+	}
+	return ds.Name
+}
+
+// applyConfigAlias overrides the stored alias with the one declared in the config file
+func (p *App) applyConfigAlias(ds *storage.DataSource) {
+	if alias, ok := p.configAliasByName[ds.Name]; ok {
+		ds.Alias = alias
+	}
+}
+
+// indexConfigAliases maps every aliased data source name to its config alias.
+// When a data source has several aliases, the first one alphabetically is displayed.
+func indexConfigAliases(aliases map[string]string) (map[string]string, error) {
+	byName := make(map[string]string, len(aliases))
+	for alias, name := range aliases {
+		if alias == "" || name == "" {
+			return nil, fmt.Errorf("invalid alias %q for %q: alias and name cannot be empty", alias, name)
+		}
+		if current, ok := byName[name]; !ok || alias < current {
+			byName[name] = alias
+		}
+	}
+	return byName, nil
+}
+
+// displayName returns the alias of the data source, falling back to its name
+func displayName(ds storage.DataSource) string {
+	if ds.Alias != "" {
+		return ds.Alias
+	}
+	return ds.Name
+}
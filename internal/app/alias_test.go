@@ -0,0 +1,87 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupDataSource(t *testing.T) {
+	p := newFakeSDMApp(t, "payments-db", "orders-db", "grafana")
+
+	p.aliases = map[string]string{
+		"orders":  "orders-db",
+		"o":       "orders-db",
+		"grafana": "payments-db", // Shadows the real grafana
+	}
+	var err error
+	p.configAliasByName, err = indexConfigAliases(p.aliases)
+	require.NoError(t, err)
+
+	require.NoError(t, p.db.SetAlias("payments-db", "pay"))
+	require.NoError(t, p.db.SetAlias("orders-db", "ord"))
+
+	// An alias stored before a resource with the same name appeared
+	require.NoError(t, p.db.SetAlias("payments-db", "redis"))
+	_, err = p.db.StoreServers([]storage.DataSource{
+		{Name: "payments-db"}, {Name: "orders-db"}, {Name: "grafana"}, {Name: "redis"},
+	}, true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		lookup    string
+		wantName  string
+		wantAlias string
+	}{
+		{"orders", "orders-db", "o"},          // Config alias, displayed as the first one alphabetically
+		{"grafana", "payments-db", "grafana"}, // Config alias before the real name
+		{"orders-db", "orders-db", "o"},       // Real name, the config alias overrides the stored one
+		{"redis", "redis", ""},                // Real name before a stored alias
+		{"ord", "orders-db", "o"},             // Stored alias
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.lookup, func(t *testing.T) {
+			ds, err := p.lookupDataSource(tc.lookup)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantName, ds.Name)
+			assert.Equal(t, tc.wantAlias, ds.Alias)
+		})
+	}
+
+	_, err = p.lookupDataSource("pay")
+	assert.ErrorIs(t, err, ErrResourceNotFound, "a replaced stored alias should no longer resolve")
+
+	_, err = p.lookupDataSource("")
+	assert.ErrorIs(t, err, ErrResourceNotFound)
+}
+
+func TestSetAlias(t *testing.T) {
+	p := newFakeSDMApp(t, "payments-db", "orders-db")
+	p.aliases = map[string]string{"orders": "orders-db"}
+
+	assert.ErrorIs(t, p.SetAlias("orders", "payments-db"), ErrConfigAlias)
+	assert.ErrorIs(t, p.RemoveAlias("orders"), ErrConfigAlias)
+	assert.ErrorIs(t, p.SetAlias("pay", "redis"), ErrResourceNotFound)
+
+	require.NoError(t, p.SetAlias("pay", "payments-db"))
+	assert.ErrorContains(t, p.SetAlias("pay", "orders-db"), "already an alias of payments-db")
+	assert.ErrorContains(t, p.SetAlias("payments-db", "orders-db"), "is the name of another datasource")
+
+	require.NoError(t, p.RemoveAlias("pay"))
+	assert.ErrorIs(t, p.RemoveAlias("pay"), ErrResourceNotFound)
+}
+
+func TestIndexConfigAliases(t *testing.T) {
+	byName, err := indexConfigAliases(map[string]string{"orders": "orders-db", "o": "orders-db", "pay": "payments-db"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"orders-db": "o", "payments-db": "pay"}, byName)
+
+	_, err = indexConfigAliases(map[string]string{"": "orders-db"})
+	assert.Error(t, err)
+
+	_, err = indexConfigAliases(map[string]string{"orders": ""})
+	assert.Error(t, err)
+}
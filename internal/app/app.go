@@ -5,15 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/marianozunino/sdm-ui/internal/daemon"
+	"github.com/marianozunino/sdm-ui/internal/filter"
 	"github.com/marianozunino/sdm-ui/internal/libsecret"
 	"github.com/marianozunino/sdm-ui/internal/logger"
 	"github.com/marianozunino/sdm-ui/internal/sdm"
 	"github.com/marianozunino/sdm-ui/internal/storage"
-	"github.com/martinlindhe/notify"
 	"github.com/rs/zerolog/log"
 )
 
@@ -27,13 +31,47 @@ type App struct {
 	db              *storage.Storage
 	dbPath          string
 	keyring         libsecret.Keyring
+	secretProvider  string
+	secretOptions   libsecret.Options
 	sdmWrapper      sdm.SDMClient
 	dmenuCommand    DMenuCommand
 	passwordCommand PasswordCommand
+	selectionAction SelectionAction
+	keybindings     []Keybinding
+	actionMenu      bool
+	icons           map[string]string // lowercased resource type -> rofi icon, from the config file
+	notifier        Notifier
+
+	launcherPreferenceNames []string
+	launcherPreference      []DMenuCommand
 
 	blacklistPatterns []string
+	filterRules       []filter.RuleConfig
+	filter            *filter.Filter
+	tagFilterExprs    []string
+	tagFilters        []TagFilter
+	sortMode          SortMode
+	aliases           map[string]string // alias -> data source name, from the config file
+	configAliasByName map[string]string // data source name -> displayed config alias
 	context           context.Context
 	timeout           time.Duration
+	retryConfig       sdm.RetryConfig
+	retryPolicy       sdm.RetryPolicy
+	listener          ListenerConfig
+	useDaemon         bool
+	daemonBackend     bool
+	daemon            *daemon.Client // set when the SDM commands go through the daemon
+	cacheTTL          time.Duration
+	maxAge            time.Duration
+
+	revalidateOnce sync.Once      // starts a single background refresh of the cache
+	background     sync.WaitGroup // background refreshes, waited for by Close
+
+	readyMu sync.Mutex // serializes the readiness check across concurrent commands
+	ready   bool       // set once the SDM listener was found ready, guarded by readyMu
+
+	loginMu  sync.Mutex // serializes re-authentication across concurrent commands
+	loginGen int        // incremented on every successful login, guarded by loginMu
 }
 
 // AppOption defines a function type that modifies App configuration
@@ -67,6 +105,34 @@ func WithBlacklist(patterns []string) AppOption {
 	}
 }
 
+// WithFilterRules sets the ordered include/exclude rules applied to resources
+func WithFilterRules(rules []filter.RuleConfig) AppOption {
+	return func(p *App) {
+		p.filterRules = rules
+	}
+}
+
+// WithTagFilters sets "key=value" / "key!=value" expressions resources must match
+func WithTagFilters(filters []string) AppOption {
+	return func(p *App) {
+		p.tagFilterExprs = filters
+	}
+}
+
+// WithSortMode sets how data sources are ordered
+func WithSortMode(mode SortMode) AppOption {
+	return func(p *App) {
+		p.sortMode = mode
+	}
+}
+
+// WithAliases sets aliases declared in the config file, keyed by alias
+func WithAliases(aliases map[string]string) AppOption {
+	return func(p *App) {
+		p.aliases = aliases
+	}
+}
+
 // WithCommand sets the menu command to use
 func WithCommand(command DMenuCommand) AppOption {
 	return func(p *App) {
@@ -74,6 +140,13 @@ func WithCommand(command DMenuCommand) AppOption {
 	}
 }
 
+// WithLauncherPreference sets the launchers tried in order by the auto launcher
+func WithLauncherPreference(names []string) AppOption {
+	return func(p *App) {
+		p.launcherPreferenceNames = names
+	}
+}
+
 // WithPasswordCommand sets the password command to use
 func WithPasswordCommand(command PasswordCommand) AppOption {
 	return func(p *App) {
@@ -81,6 +154,47 @@ func WithPasswordCommand(command PasswordCommand) AppOption {
 	}
 }
 
+// WithSelectionAction sets the action applied to the data source picked in a menu
+func WithSelectionAction(action SelectionAction) AppOption {
+	return func(p *App) {
+		p.selectionAction = action
+	}
+}
+
+// WithKeybindings sets the rofi custom keys and the actions they run, replacing the defaults
+func WithKeybindings(keybindings []Keybinding) AppOption {
+	return func(p *App) {
+		if len(keybindings) > 0 {
+			p.keybindings = keybindings
+		}
+	}
+}
+
+// WithActionMenu shows a menu of actions for the picked data source instead of running the selection action
+func WithActionMenu(enabled bool) AppOption {
+	return func(p *App) {
+		p.actionMenu = enabled
+	}
+}
+
+// WithIcons sets the rofi icons of resource types, overriding the defaults
+func WithIcons(icons map[string]string) AppOption {
+	return func(p *App) {
+		p.icons = make(map[string]string, len(icons))
+		for resourceType, icon := range icons {
+			p.icons[strings.ToLower(resourceType)] = icon
+		}
+	}
+}
+
+// WithSecretProvider sets where account passwords are stored, the system keyring by default
+func WithSecretProvider(provider string, opts libsecret.Options) AppOption {
+	return func(p *App) {
+		p.secretProvider = provider
+		p.secretOptions = opts
+	}
+}
+
 // WithTimeout sets a timeout for operations
 func WithTimeout(timeout time.Duration) AppOption {
 	return func(p *App) {
@@ -88,6 +202,13 @@ func WithTimeout(timeout time.Duration) AppOption {
 	}
 }
 
+// WithRetry sets how failed SDM commands are retried
+func WithRetry(cfg sdm.RetryConfig) AppOption {
+	return func(p *App) {
+		p.retryConfig = cfg
+	}
+}
+
 // WithContext sets a context for the application
 func WithContext(ctx context.Context) AppOption {
 	return func(p *App) {
@@ -103,19 +224,86 @@ func NewApp(opts ...AppOption) (*App, error) {
 		dmenuCommand:      DMenuCommandRofi,
 		blacklistPatterns: []string{},
 		passwordCommand:   PasswordCommandZenity,
+		selectionAction:   SelectionActionConnect,
+		keybindings:       DefaultKeybindings,
+		sortMode:          SortFrecency,
 		context:           context.Background(),
 		timeout:           30 * time.Second, // Default timeout
+		cacheTTL:          DefaultCacheTTL,
+		notifier:          DesktopNotifier,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
+	resourceFilter, err := p.buildFilter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter configuration: %w", err)
+	}
+	p.filter = resourceFilter
+
+	if p.sortMode, err = ParseSortMode(p.sortMode.String()); err != nil {
+		return nil, err
+	}
+
+	if p.retryPolicy, err = sdm.NewRetryPolicy(p.retryConfig); err != nil {
+		return nil, err
+	}
+
+	if p.listener.ReadyTimeout < 0 {
+		return nil, fmt.Errorf("invalid listener: readyTimeout can't be negative")
+	}
+
+	if p.cacheTTL < 0 || p.maxAge < 0 {
+		return nil, fmt.Errorf("invalid cache: cacheTTL and max age can't be negative")
+	}
+
+	if p.configAliasByName, err = indexConfigAliases(p.aliases); err != nil {
+		return nil, fmt.Errorf("invalid aliases: %w", err)
+	}
+
+	tagFilters, err := ParseTagFilters(p.tagFilterExprs)
+	if err != nil {
+		return nil, err
+	}
+	p.tagFilters = tagFilters
+
+	if err := validateKeybindings(p.keybindings); err != nil {
+		return nil, err
+	}
+
+	if p.launcherPreference, err = parseLauncherPreference(p.launcherPreferenceNames); err != nil {
+		return nil, fmt.Errorf("invalid launcherPreference: %w", err)
+	}
+
+	if err := p.resolveLauncher(); err != nil {
+		return nil, err
+	}
+
+	if err := p.resolvePasswordCommand(); err != nil {
+		return nil, err
+	}
+
+	if p.keyring, err = libsecret.New(p.secretProvider, p.secretOptions); err != nil {
+		return nil, fmt.Errorf("invalid secretProvider: %w", err)
+	}
+
 	if err := p.mustHaveDependencies(); err != nil {
 		return nil, fmt.Errorf("dependency check failed: %w", err)
 	}
 
-	db, err := storage.NewStorage(p.account, p.dbPath)
+	if p.useDaemon {
+		p.connectDaemon()
+	}
+
+	// The daemon and its clients share the cache, none of them keeps it locked while idle
+	var storageOpts []storage.StorageOption
+	if p.daemonBackend || p.daemon != nil {
+		storageOpts = append(storageOpts, storage.WithShared())
+	}
+
+	db, err := storage.NewStorage(p.account, p.dbPath, storageOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -127,6 +315,8 @@ func NewApp(opts ...AppOption) (*App, error) {
 
 // Close closes all resources held by the App
 func (p *App) Close() error {
+	p.background.Wait()
+
 	if p.db != nil {
 		return p.db.Close()
 	}
@@ -143,34 +333,89 @@ func (p *App) ValidateAccount() error {
 		return fmt.Errorf("ready check failed: %w", err)
 	}
 
-	if status.Account != nil && *status.Account != p.account {
-		log.Debug().
-			Str("current", *status.Account).
-			Str("expected", p.account).
-			Msg("Logged in with a different account, logging out")
+	return p.logoutOtherAccount(status)
+}
+
+// logoutOtherAccount logs out when SDM reports another account than the configured one
+func (p *App) logoutOtherAccount(status sdm.SdmReady) error {
+	if status.Account == nil || *status.Account == p.account {
+		return nil
+	}
+
+	log.Debug().
+		Str("current", *status.Account).
+		Str("expected", p.account).
+		Msg("Logged in with a different account, logging out")
 
-		if err := p.sdmWrapper.LogoutWithContext(ctx); err != nil {
-			var sdmErr sdm.SDMError
-			if errors.As(err, &sdmErr) && sdmErr.Code == sdm.Unauthorized {
-				// Already logged out
-				return nil
-			}
-			return fmt.Errorf("failed to logout: %w", err)
+	ctx, cancel := context.WithTimeout(p.context, p.timeout)
+	defer cancel()
+
+	if err := p.sdmWrapper.LogoutWithContext(ctx); err != nil {
+		var sdmErr sdm.SDMError
+		if errors.As(err, &sdmErr) && sdmErr.Code == sdm.Unauthorized {
+			// Already logged out
+			return nil
 		}
+		return fmt.Errorf("failed to logout: %w", err)
+	}
+
+	return nil
+}
+
+// SwitchAccount logs in the configured account, logging out any other account first.
+// It checks SDM itself: ensureReady leaves that to the daemon, which never logs out.
+func (p *App) SwitchAccount() error {
+	status, err := p.waitReady()
+	if err != nil {
+		return err
+	}
+
+	if status.Account != nil && *status.Account == p.account {
+		log.Debug().Str("account", p.account).Msg("Already logged in")
+		p.notify("👤 Already logged in", p.account)
+		return nil
 	}
 
+	if err := p.logoutOtherAccount(status); err != nil {
+		return err
+	}
+
+	p.loginMu.Lock()
+	gen := p.loginGen
+	p.loginMu.Unlock()
+
+	if err := p.login(gen); err != nil {
+		return err
+	}
+
+	p.notify("👤 Switched account", p.account)
 	return nil
 }
 
-// PrintDataSources formats and writes data sources to the provided writer
+// PrintDataSources formats and writes data sources to the provided writer.
+// Aliased data sources are listed by alias with their real name in a trailing column.
 func (p *App) PrintDataSources(dataSources []storage.DataSource, w io.Writer, withHeaders bool) {
-	const format = "%v\t%v\t%v\n"
+	hasAliases := slices.ContainsFunc(dataSources, func(ds storage.DataSource) bool {
+		return ds.Alias != ""
+	})
+
+	format := "%v\t%v\t%v\n"
+	if hasAliases {
+		format = "%v\t%v\t%v\t%v\n"
+	}
 	tw := tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+	row := func(name, address, status, resource string) {
+		if hasAliases {
+			fmt.Fprintf(tw, format, name, address, status, resource)
+			return
+		}
+		fmt.Fprintf(tw, format, name, address, status)
+	}
 
 	// Write header
 	if withHeaders {
-		fmt.Fprintf(tw, format, "NAME", "ADDRESS", "STATUS")
-		fmt.Fprintf(tw, format, "----", "-------", "------")
+		row("NAME", "ADDRESS", "STATUS", "RESOURCE")
+		row("----", "-------", "------", "--------")
 	}
 
 	for _, ds := range dataSources {
@@ -184,7 +429,16 @@ func (p *App) PrintDataSources(dataSources []storage.DataSource, w io.Writer, wi
 			status = "🌐"
 		}
 
-		fmt.Fprintf(tw, format, ds.Name, Ellipsize(ds.Address, 20), status)
+		if ds.Pinned {
+			status = "📌" + status
+		}
+
+		resource := ""
+		if ds.Alias != "" {
+			resource = ds.Name
+		}
+
+		row(displayName(ds), Ellipsize(ds.Address, 20), status, resource)
 	}
 	tw.Flush()
 }
@@ -199,38 +453,66 @@ func Ellipsize(s string, maxLen int) string {
 
 // RetryCommand executes the provided function and handles common errors
 func (p *App) RetryCommand(exec func() error) error {
-	err := exec()
+	if err := p.ensureReady(); err != nil {
+		return err
+	}
+
+	p.loginMu.Lock()
+	gen := p.loginGen
+	p.loginMu.Unlock()
+
+	err := p.retryPolicy.Do(p.context, exec)
 	if err == nil {
 		return nil
 	}
 
+	if p.context.Err() != nil {
+		return fmt.Errorf("command canceled: %w", err)
+	}
+
 	var sdmErr sdm.SDMError
 	if !errors.As(err, &sdmErr) {
-		notify.Notify("SDM CLI", "❗Unexpected error", err.Error(), "")
+		p.notify("❗Unexpected error", err.Error())
 		return fmt.Errorf("unexpected error: %w", err)
 	}
 
 	switch sdmErr.Code {
 	case sdm.Unauthorized:
-		return p.handleUnauthorized(exec)
+		return p.handleUnauthorized(exec, gen)
 	case sdm.InvalidCredentials:
 		return p.handleInvalidCredentials(sdmErr)
 	case sdm.ResourceNotFound:
-		notify.Notify("SDM CLI", "🔐 Resource not found", sdmErr.Error(), "")
+		p.notify("🔐 Resource not found", sdmErr.Error())
 		return fmt.Errorf("%w: %v", ErrResourceNotFound, sdmErr)
 	default:
-		notify.Notify("SDM CLI", "🔐 Error", sdmErr.Error(), "")
+		p.notify("🔐 Error", sdmErr.Error())
 		return fmt.Errorf("command error: %w", sdmErr)
 	}
 }
 
 // HandleUnauthorized handles unauthorized errors by re-authenticating
-func (p *App) handleUnauthorized(command func() error) error {
-	notify.Notify("SDM CLI", "🔐 Authenticating...", "", "")
+func (p *App) handleUnauthorized(command func() error, gen int) error {
+	if err := p.login(gen); err != nil {
+		return err
+	}
+	return p.retryPolicy.Do(p.context, command)
+}
+
+// login authenticates the account unless another command already did so since gen was read
+func (p *App) login(gen int) error {
+	p.loginMu.Lock()
+	defer p.loginMu.Unlock()
+
+	if p.loginGen != gen {
+		log.Debug().Msg("Already logged in by a concurrent command")
+		return nil
+	}
+
+	p.notify("🔐 Authenticating...", "")
 
 	password, err := p.retrievePassword()
 	if err != nil {
-		notify.Notify("SDM CLI", "🔐 Authentication error", err.Error(), "")
+		p.notify("🔐 Authentication error", err.Error())
 		return fmt.Errorf("failed to retrieve password: %w", err)
 	}
 
@@ -240,18 +522,19 @@ func (p *App) handleUnauthorized(command func() error) error {
 	defer cancel()
 
 	if err := p.sdmWrapper.LoginWithContext(ctx, p.account, password); err != nil {
-		p.keyring.DeleteSecret(p.account)
-		notify.Notify("SDM CLI", "🔐 Authentication error", err.Error(), "")
+		p.forgetPassword()
+		p.notify("🔐 Authentication error", err.Error())
 		return fmt.Errorf("login failed: %w", err)
 	}
 
 	log.Debug().Msg("Login successful")
-	return command()
+	p.loginGen++
+	return nil
 }
 
 // HandleInvalidCredentials handles invalid credential errors
 func (p *App) handleInvalidCredentials(err sdm.SDMError) error {
-	notify.Notify("SDM CLI", "🔐 Authentication error", "Invalid credentials", "")
-	p.keyring.DeleteSecret(p.account)
+	p.notify("🔐 Authentication error", "Invalid credentials")
+	p.forgetPassword()
 	return fmt.Errorf("invalid credentials: %w", err)
 }
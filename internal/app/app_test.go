@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/daemon"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitchAccount_WithDaemon(t *testing.T) {
+	// The session file holds the account logged in
+	exe := fakeProgram(t, "sdm", `state="$(dirname "$0")"
+case "$1" in
+ready)
+	if [ -f "$state/session" ]; then
+		echo "{\"account\":\"$(cat "$state/session")\",\"listener_running\":true,\"state_loaded\":true}"
+	else
+		echo '{"listener_running":true,"state_loaded":false}'
+	fi ;;
+logout)
+	rm "$state/session"
+	touch "$state/logged-out" ;;
+login)
+	if [ -f "$state/session" ]; then
+		echo "already logged in as $(cat "$state/session")" >&2
+		exit 1
+	fi
+	echo "$3" > "$state/session" ;;
+esac`)
+	state := filepath.Dir(exe)
+	require.NoError(t, os.WriteFile(filepath.Join(state, "session"), []byte("other@example.com\n"), 0o600))
+
+	p := &App{
+		account:    "me@example.com",
+		keyring:    testKeyring{},
+		sdmWrapper: *sdm.NewSDMClient(exe),
+		context:    context.Background(),
+		timeout:    5 * time.Second,
+		// The commands of the account go through its daemon
+		daemon: daemon.NewClient(filepath.Join(t.TempDir(), "daemon.sock")),
+	}
+
+	require.NoError(t, p.SwitchAccount())
+	assert.FileExists(t, filepath.Join(state, "logged-out"), "the other account should be logged out")
+
+	session, err := os.ReadFile(filepath.Join(state, "session"))
+	require.NoError(t, err)
+	assert.Equal(t, "me@example.com\n", string(session))
+}
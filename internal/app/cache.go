@@ -0,0 +1,96 @@
+package app
+
+import (
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultCacheTTL is how long the cache is considered fresh when no cacheTTL is configured
+const DefaultCacheTTL = time.Hour
+
+// cacheAction is what to do with the cache before using it
+type cacheAction int
+
+const (
+	cacheFresh      cacheAction = iota // Use the cache as is
+	cacheRevalidate                    // Use the cache and refresh it in the background
+	cacheSync                          // Refresh the cache before using it
+)
+
+// WithCacheTTL sets how long the cache is used before being refreshed in the background, 0 never refreshes it
+func WithCacheTTL(ttl time.Duration) AppOption {
+	return func(p *App) {
+		p.cacheTTL = ttl
+	}
+}
+
+// WithMaxAge makes the cache be refreshed before being used when it is older than maxAge, 0 disables it
+func WithMaxAge(maxAge time.Duration) AppOption {
+	return func(p *App) {
+		p.maxAge = maxAge
+	}
+}
+
+// cacheAction decides whether the cache holding count data sources, last synced at syncedAt,
+// must be refreshed. A cache that was never synced is as stale as it gets.
+func (p *App) cacheAction(count int, syncedAt time.Time, now time.Time) cacheAction {
+	if count == 0 {
+		return cacheSync
+	}
+
+	stale := func(limit time.Duration) bool {
+		return limit > 0 && (syncedAt.IsZero() || now.Sub(syncedAt) > limit)
+	}
+
+	switch {
+	case stale(p.maxAge):
+		return cacheSync
+	case stale(p.cacheTTL):
+		return cacheRevalidate
+	default:
+		return cacheFresh
+	}
+}
+
+// revalidate refreshes the cache in the background, at most once per application.
+// Close waits for the refresh so that the next command sees it.
+func (p *App) revalidate() {
+	p.revalidateOnce.Do(func() {
+		p.background.Add(1)
+		go func() {
+			defer p.background.Done()
+
+			if err := p.refreshCache(); err != nil {
+				log.Debug().Err(err).Msg("Background cache refresh failed")
+			}
+		}()
+	})
+}
+
+// refreshCache stores the current resources without logging in, which would prompt for a
+// password while a menu is open. A stale cache is kept when the session expired.
+func (p *App) refreshCache() error {
+	var resources []sdm.Resource
+	err := p.retryPolicy.Do(p.context, func() error {
+		var err error
+		resources, err = p.status()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.StoreServers(parseDataSources(resources), completeStatus(resources))
+	if err != nil {
+		return err
+	}
+
+	log.Debug().
+		Int("added", len(result.Added)).
+		Int("removed", len(result.Removed)).
+		Int("changed", len(result.Changed)).
+		Msg("Cache refreshed in the background")
+	return nil
+}
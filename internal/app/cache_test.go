@@ -0,0 +1,37 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheAction(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		cacheTTL time.Duration
+		maxAge   time.Duration
+		count    int
+		syncedAt time.Time
+		expected cacheAction
+	}{
+		{"empty cache", time.Hour, 0, 0, now, cacheSync},
+		{"fresh cache", time.Hour, 0, 3, now.Add(-time.Minute), cacheFresh},
+		{"stale cache", time.Hour, 0, 3, now.Add(-2 * time.Hour), cacheRevalidate},
+		{"never synced", time.Hour, 0, 3, time.Time{}, cacheRevalidate},
+		{"ttl disabled", 0, 0, 3, now.Add(-48 * time.Hour), cacheFresh},
+		{"older than max age", time.Hour, 10 * time.Minute, 3, now.Add(-15 * time.Minute), cacheSync},
+		{"within max age", time.Hour, 10 * time.Minute, 3, now.Add(-5 * time.Minute), cacheFresh},
+		{"never synced with max age", time.Hour, 10 * time.Minute, 3, time.Time{}, cacheSync},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &App{cacheTTL: tc.cacheTTL, maxAge: tc.maxAge}
+			assert.Equal(t, tc.expected, p.cacheAction(tc.count, tc.syncedAt, now))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// maxParallelConnections bounds how many sdm connect commands run at once
+const maxParallelConnections = 4
+
+// ErrConnectFailed indicates that at least one connection could not be established
+var ErrConnectFailed = errors.New("failed to connect to some resources")
+
+// ConnectResult is the outcome of connecting to a single data source
+type ConnectResult struct {
+	Target     string // Name, alias or pattern that selected the data source
+	DataSource storage.DataSource
+	Err        error
+}
+
+// Connect connects to every data source selected by the targets and writes a per-resource summary.
+// Targets are names, aliases or glob patterns narrowed by the configured tag filters. When no
+// target is given, every data source matching the selecting tags, and only those, is connected.
+func (p *App) Connect(w io.Writer, targets []string, selectTags []string) error {
+	if len(targets) == 0 && len(selectTags) == 0 {
+		return errors.New("requires a resource name, pattern or tag filter")
+	}
+
+	var results []ConnectResult
+	if len(targets) == 0 {
+		filters, err := ParseTagFilters(selectTags)
+		if err != nil {
+			return err
+		}
+		results, err = p.resolveConnectTags(filters)
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		results, err = p.resolveConnectTargets(targets)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.connectAll(results)
+
+	log.Debug().Msg("Syncing data sources after connection")
+	if err := p.Sync(); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync data sources after connection")
+	}
+
+	return writeConnectSummary(w, results)
+}
+
+// resolveConnectTags selects the data sources matching the tag filters, ignoring the configured ones
+func (p *App) resolveConnectTags(filters []TagFilter) ([]ConnectResult, error) {
+	candidates, err := p.sortedDataSources(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no resources match the tag filters", ErrResourceNotFound)
+	}
+
+	results := make([]ConnectResult, 0, len(candidates))
+	for _, ds := range candidates {
+		results = append(results, ConnectResult{Target: ds.Name, DataSource: ds})
+	}
+	return results, nil
+}
+
+// resolveConnectTargets expands the targets into the data sources to connect to. Names missing
+// from the cache may be new resources, the cache is synced once before resolving them all.
+func (p *App) resolveConnectTargets(targets []string) ([]ConnectResult, error) {
+	uncached := slices.ContainsFunc(targets, func(target string) bool {
+		if isGlob(target) {
+			return false
+		}
+		_, err := p.lookupDataSource(target)
+		return errors.Is(err, ErrResourceNotFound)
+	})
+	if uncached {
+		log.Debug().Msg("Some resources aren't cached, syncing before connecting")
+		if err := p.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	candidates, err := p.GetSortedDataSources()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ConnectResult, 0, len(targets))
+	seen := make(map[string]bool)
+	add := func(target string, ds storage.DataSource) {
+		if seen[ds.Name] {
+			return
+		}
+		seen[ds.Name] = true
+		results = append(results, ConnectResult{Target: target, DataSource: ds})
+	}
+
+	for _, target := range targets {
+		if !isGlob(target) {
+			ds, err := p.lookupDataSource(target)
+			if err != nil {
+				results = append(results, ConnectResult{Target: target, DataSource: storage.DataSource{Name: target}, Err: err})
+				continue
+			}
+			if !matchesTagFilters(ds.Tags, p.tagFilters) {
+				results = append(results, ConnectResult{Target: target, DataSource: ds, Err: fmt.Errorf("%w: %s does not match the tag filters", ErrResourceNotFound, target)})
+				continue
+			}
+			add(target, ds)
+			continue
+		}
+
+		matched := false
+		for _, ds := range candidates {
+			if matchGlob(target, ds.Name) || (ds.Alias != "" && matchGlob(target, ds.Alias)) {
+				add(target, ds)
+				matched = true
+			}
+		}
+		if !matched {
+			results = append(results, ConnectResult{Target: target, DataSource: storage.DataSource{Name: target}, Err: fmt.Errorf("%w: no resources match %s", ErrResourceNotFound, target)})
+		}
+	}
+
+	return results, nil
+}
+
+// writeConnectSummary writes one line per result and reports whether any failed
+func writeConnectSummary(w io.Writer, results []ConnectResult) error {
+	const format = "%v\t%v\t%v\n"
+	tw := tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(tw, format, "✘", result.DataSource.Name, result.Err)
+			continue
+		}
+		fmt.Fprintf(tw, format, "✔", result.DataSource.Name, result.DataSource.Address)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed", ErrConnectFailed, failed, len(results))
+	}
+	return nil
+}
+
+// isGlob reports whether the target contains glob metacharacters
+func isGlob(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// matchGlob matches a name against a glob pattern, treating malformed patterns as non-matches
+func matchGlob(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// connectAll connects to the data source of every result without an error yet,
+// running at most maxParallelConnections connections at a time
+func (p *App) connectAll(results []ConnectResult) {
+	sem := make(chan struct{}, maxParallelConnections)
+	var wg sync.WaitGroup
+
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *ConnectResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.Err = p.connect(result.DataSource)
+		}(&results[i])
+	}
+
+	wg.Wait()
+}
+
+// connect connects to the data source, logging in again if needed, and records its use
+func (p *App) connect(ds storage.DataSource) error {
+	log.Debug().
+		Str("name", ds.Name).
+		Str("address", ds.Address).
+		Msg("Connecting to data source")
+
+	if err := p.RetryCommand(func() error {
+		return p.sdmConnect(ds.Name)
+	}); err != nil {
+		log.Error().
+			Err(err).
+			Str("datasource", ds.Name).
+			Msg("Failed to connect to data source")
+		return err
+	}
+
+	// Record the use once the connection succeeded, however many attempts it took
+	if err := p.db.UpdateLastUsed(ds); err != nil {
+		log.Warn().
+			Err(err).
+			Str("datasource", ds.Name).
+			Msg("Failed to update last used timestamp")
+	}
+
+	log.Debug().
+		Str("name", ds.Name).
+		Msg("Successfully connected to data source")
+	return nil
+}
+
+// connectDataSource connects to the data source, notifies the user and refreshes the cache
+func (p *App) connectDataSource(ds storage.DataSource) error {
+	if err := p.connect(ds); err != nil {
+		return err
+	}
+
+	// Notify user of successful connection
+	p.notifyDataSourceConnected(ds)
+
+	// Sync data sources
+	log.Debug().Msg("Syncing data sources after connection")
+	if err := p.Sync(); err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Failed to sync data sources after connection")
+	}
+
+	return nil
+}
+
+// connectDataSources connects to the data sources in parallel, notifies the user once
+// and refreshes the cache
+func (p *App) connectDataSources(dataSources []storage.DataSource) error {
+	results := make([]ConnectResult, 0, len(dataSources))
+	for _, ds := range dataSources {
+		results = append(results, ConnectResult{Target: ds.Name, DataSource: ds})
+	}
+
+	p.connectAll(results)
+	p.notifyDataSourcesConnected(results)
+
+	log.Debug().Msg("Syncing data sources after connection")
+	if err := p.Sync(); err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Failed to sync data sources after connection")
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed", ErrConnectFailed, failed, len(results))
+	}
+	return nil
+}
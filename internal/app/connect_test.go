@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/filter"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSDMScript behaves like sdm for an account that must log in before connecting, its status
+// printing the status file next to the script. Resources named broken-* never connect nor
+// disconnect, flaky-* ones fail to connect once like a restarting listener.
+const fakeSDMScript = `state="$(dirname "$0")"
+case "$1" in
+ready)
+	if [ -f "$state/session" ]; then
+		echo '{"account":"me@example.com","listener_running":true,"state_loaded":true}'
+	else
+		echo '{"listener_running":true,"state_loaded":false}'
+	fi ;;
+login)
+	echo . >> "$state/logins"
+	touch "$state/session"
+	echo "logged in" ;;
+connect)
+	if [ ! -f "$state/session" ]; then
+		echo "You are not authenticated. Please login again."
+		exit 9
+	fi
+	case "$2" in
+	broken-*)
+		echo "Connection refused"
+		exit 1 ;;
+	flaky-*)
+		if [ ! -f "$state/$2.tried" ]; then
+			touch "$state/$2.tried"
+			echo "Connection refused"
+			exit 1
+		fi ;;
+	esac
+	echo "connected" ;;
+disconnect)
+	if [ ! -f "$state/session" ]; then
+		echo "You are not authenticated. Please login again."
+		exit 9
+	fi
+	case "$2" in
+	broken-*)
+		echo "Connection refused"
+		exit 1 ;;
+	esac
+	echo "disconnected" ;;
+status)
+	if [ ! -f "$state/session" ]; then
+		echo "You are not authenticated. Please login again."
+		exit 9
+	fi
+	echo . >> "$state/statuses"
+	cat "$state/status" 2>/dev/null || echo null ;;
+*)
+	echo "unexpected command $1" >&2
+	exit 1 ;;
+esac`
+
+// testKeyring always returns the same password
+type testKeyring struct{}
+
+func (testKeyring) GetSecret(email string) (string, error)      { return "secret", nil }
+func (testKeyring) SetSecret(email string, secret string) error { return nil }
+func (testKeyring) DeleteSecret(email string) error             { return nil }
+
+// newFakeSDMApp creates an application logged out of the fake sdm, caching the named resources
+func newFakeSDMApp(t *testing.T, names ...string) *App {
+	t.Helper()
+
+	exe := fakeProgram(t, "sdm", fakeSDMScript)
+
+	db, err := storage.NewStorage("me@example.com", t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	dataSources := make([]storage.DataSource, 0, len(names))
+	for _, name := range names {
+		dataSources = append(dataSources, storage.DataSource{Name: name, Address: "localhost:10001"})
+	}
+	_, err = db.StoreServers(dataSources, true)
+	require.NoError(t, err)
+
+	retryPolicy, err := sdm.NewRetryPolicy(sdm.RetryConfig{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	require.NoError(t, err)
+
+	return &App{
+		account:     "me@example.com",
+		db:          db,
+		keyring:     testKeyring{},
+		sdmWrapper:  *sdm.NewSDMClient(exe),
+		context:     context.Background(),
+		timeout:     5 * time.Second,
+		retryPolicy: retryPolicy,
+		filter:      filter.New(),
+	}
+}
+
+// fakeSDMLogins returns how many times the fake sdm of the application logged in
+func fakeSDMLogins(t *testing.T, p *App) int {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(p.sdmWrapper.CommandRunner.Exe), "logins"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return strings.Count(string(data), ".")
+}
+
+// fakeSDMStatuses returns how many times the fake sdm of the application listed the resources
+func fakeSDMStatuses(t *testing.T, p *App) int {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(p.sdmWrapper.CommandRunner.Exe), "statuses"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return strings.Count(string(data), ".")
+}
+
+// useCount returns how many uses of the data source were recorded
+func useCount(t *testing.T, p *App, name string) int {
+	t.Helper()
+
+	ds, err := p.db.GetDatasource(name)
+	require.NoError(t, err)
+	return ds.UseCount
+}
+
+func TestConnect_RecordsOneUse(t *testing.T) {
+	p := newFakeSDMApp(t, "payments-db", "flaky-db", "broken-db")
+
+	// Logs in after the first attempt fails as unauthorized
+	require.NoError(t, p.connect(storage.DataSource{Name: "payments-db", Address: "localhost:10001"}))
+	// Retried after the connection is refused once
+	require.NoError(t, p.connect(storage.DataSource{Name: "flaky-db", Address: "localhost:10001"}))
+	// Fails every attempt
+	require.Error(t, p.connect(storage.DataSource{Name: "broken-db", Address: "localhost:10001"}))
+
+	assert.Equal(t, 1, fakeSDMLogins(t, p))
+	assert.Equal(t, 1, useCount(t, p, "payments-db"), "a connect retried after logging in is a single use")
+	assert.Equal(t, 1, useCount(t, p, "flaky-db"), "a retried connect is a single use")
+	assert.Equal(t, 0, useCount(t, p, "broken-db"), "a failed connect is not a use")
+}
+
+func TestConnectAll_ConcurrentUnauthorized(t *testing.T) {
+	names := []string{"payments-db", "orders-db", "grafana", "redis", "kafka", "broken-db"}
+	p := newFakeSDMApp(t, names...)
+
+	results := make([]ConnectResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, ConnectResult{Target: name, DataSource: storage.DataSource{Name: name, Address: "localhost:10001"}})
+	}
+
+	// Every connection fails as unauthorized until one of them logs in
+	p.connectAll(results)
+
+	assert.Equal(t, 1, fakeSDMLogins(t, p), "concurrent connects should share a single login")
+	for _, result := range results {
+		if result.DataSource.Name == "broken-db" {
+			assert.Error(t, result.Err)
+			assert.Equal(t, 0, useCount(t, p, "broken-db"))
+			continue
+		}
+		assert.NoError(t, result.Err, result.DataSource.Name)
+		assert.Equal(t, 1, useCount(t, p, result.DataSource.Name), result.DataSource.Name)
+	}
+}
+
+func TestConnect_SelectTags(t *testing.T) {
+	p := newFakeSDMApp(t)
+	_, err := p.db.StoreServers([]storage.DataSource{
+		{Name: "payments-db", Tags: map[string]string{"env": "prod", "team": "payments"}},
+		{Name: "payments-cache", Tags: map[string]string{"env": "staging", "team": "payments"}},
+		{Name: "orders-db", Tags: map[string]string{"env": "prod", "team": "orders"}},
+	}, true)
+	require.NoError(t, err)
+
+	// Configured tag filters don't narrow the resources selected by tags
+	p.tagFilters, err = ParseTagFilters([]string{"env=prod", "team=payments"})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, p.Connect(&out, nil, []string{"team=payments"}))
+	assert.Contains(t, out.String(), "payments-db")
+	assert.Contains(t, out.String(), "payments-cache")
+	assert.NotContains(t, out.String(), "orders-db")
+
+	assert.Error(t, p.Connect(&out, nil, nil), "connecting without a name requires tags")
+	assert.ErrorIs(t, p.Connect(&out, nil, []string{"team=billing"}), ErrResourceNotFound)
+}
+
+func TestResolveConnectTargets_SyncsOnce(t *testing.T) {
+	p := newFakeSDMApp(t, "payments-db")
+	status := `[
+	{"id":"rs-1","name":"payments-db","type":"postgres","address":"localhost:10001","connection_status":"not connected","tags":""},
+	{"id":"rs-2","name":"orders-db","type":"postgres","address":"localhost:10002","connection_status":"not connected","tags":""}
+]`
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(p.sdmWrapper.CommandRunner.Exe), "status"), []byte(status), 0o600))
+
+	// orders-db is new, the other unknown names were never granted
+	results, err := p.resolveConnectTargets([]string{"payments-db", "orders-db", "typo-a", "typo-b", "typo-*"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, fakeSDMStatuses(t, p), "unknown names should share a single sync")
+	require.Len(t, results, 5)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "localhost:10002", results[1].DataSource.Address)
+	for _, result := range results[2:] {
+		assert.ErrorIs(t, result.Err, ErrResourceNotFound, result.Target)
+	}
+
+	// Cached names don't sync
+	_, err = p.resolveConnectTargets([]string{"payments-db", "orders-db"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fakeSDMStatuses(t, p))
+}
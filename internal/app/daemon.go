@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marianozunino/sdm-ui/internal/daemon"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrAccountMismatch indicates that SDM is logged in with another account than the daemon's
+var ErrAccountMismatch = errors.New("sdm is logged in with another account")
+
+// WithDaemon makes the application go through the daemon of the account when it is running
+func WithDaemon() AppOption {
+	return func(p *App) {
+		p.useDaemon = true
+	}
+}
+
+// WithDaemonBackend prepares the application to back a daemon, which only opens the cache while
+// storing the resources so that the other commands can still use it
+func WithDaemonBackend() AppOption {
+	return func(p *App) {
+		p.daemonBackend = true
+	}
+}
+
+// connectDaemon uses the daemon of the account for the SDM commands when one is running
+func (p *App) connectDaemon() {
+	path, err := daemon.SocketPath(p.account)
+	if err != nil {
+		log.Debug().Err(err).Msg("Not using the daemon")
+		return
+	}
+
+	client := daemon.NewClient(path)
+	if !client.Running(p.context) {
+		log.Debug().Str("socket", path).Msg("Daemon not running, running SDM commands directly")
+		return
+	}
+
+	log.Debug().Str("socket", path).Msg("Using the daemon")
+	p.daemon = client
+}
+
+// daemonFailed reports whether the daemon could not run the request, e.g. because it stopped.
+// SDM errors are sent back by the daemon and are handled like the ones of direct commands.
+func (p *App) daemonFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sdmErr sdm.SDMError
+	if errors.As(err, &sdmErr) {
+		return false
+	}
+
+	log.Warn().Err(err).Msg("Daemon request failed, running the SDM command directly")
+	return true
+}
+
+// status returns the resources, from the daemon's memory when it is running
+func (p *App) status() ([]sdm.Resource, error) {
+	if p.daemon != nil {
+		resources, err := p.daemon.List(p.context)
+		if !p.daemonFailed(err) {
+			return resources, err
+		}
+	}
+	return p.sdmWrapper.StatusWithContext(p.context)
+}
+
+// sdmConnect connects to the data source, through the daemon when it is running
+func (p *App) sdmConnect(name string) error {
+	if p.daemon != nil {
+		if err := p.daemon.Connect(p.context, name); !p.daemonFailed(err) {
+			return err
+		}
+	}
+	return p.sdmWrapper.ConnectWithContext(p.context, name)
+}
+
+// sdmDisconnect disconnects from the data source, through the daemon when it is running
+func (p *App) sdmDisconnect(name string) error {
+	if p.daemon != nil {
+		if err := p.daemon.Disconnect(p.context, name); !p.daemonFailed(err) {
+			return err
+		}
+	}
+	return p.sdmWrapper.DisconnectWithContext(p.context, name)
+}
+
+// DaemonBackend returns the backend running the SDM commands of the daemon
+func (p *App) DaemonBackend() daemon.Backend {
+	return daemonBackend{p}
+}
+
+// daemonBackend runs the SDM commands of the daemon directly, without prompting for a password
+type daemonBackend struct {
+	p *App
+}
+
+// Status implements daemon.Backend
+func (b daemonBackend) Status(ctx context.Context) ([]sdm.Resource, error) {
+	var resources []sdm.Resource
+	err := b.run(ctx, func() error {
+		var err error
+		resources, err = b.p.sdmWrapper.StatusWithContext(ctx)
+		return err
+	})
+	return resources, err
+}
+
+// Connect implements daemon.Backend
+func (b daemonBackend) Connect(ctx context.Context, name string) error {
+	return b.run(ctx, func() error {
+		return b.p.sdmWrapper.ConnectWithContext(ctx, name)
+	})
+}
+
+// Disconnect implements daemon.Backend
+func (b daemonBackend) Disconnect(ctx context.Context, name string) error {
+	return b.run(ctx, func() error {
+		return b.p.sdmWrapper.DisconnectWithContext(ctx, name)
+	})
+}
+
+// run runs the command once SDM is ready for the account of the daemon
+func (b daemonBackend) run(ctx context.Context, command func() error) error {
+	if err := b.checkReady(); err != nil {
+		return err
+	}
+	return b.p.retryPolicy.Do(ctx, command)
+}
+
+// checkReady waits for the listener and fails when another account is logged in. The daemon
+// outlives the listener and the logins, so it checks them before every command. Unlike
+// ensureReady it never logs out: the user may have switched to that account on purpose.
+func (b daemonBackend) checkReady() error {
+	status, err := b.p.waitReady()
+	if err != nil {
+		return err
+	}
+	return b.checkAccount(status)
+}
+
+// checkAccount fails when SDM is logged in with another account than the daemon's
+func (b daemonBackend) checkAccount(status sdm.SdmReady) error {
+	if status.Account != nil && *status.Account != b.p.account {
+		return fmt.Errorf("%w: %s", ErrAccountMismatch, *status.Account)
+	}
+	return nil
+}
+
+// Store implements daemon.Backend. The resources are only stored while SDM is still logged in
+// with the account of the daemon, those of another account would replace its cache.
+func (b daemonBackend) Store(resources []sdm.Resource) error {
+	ctx, cancel := context.WithTimeout(b.p.context, b.p.timeout)
+	status, err := b.p.sdmWrapper.ReadyWithContext(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("ready check failed: %w", err)
+	}
+
+	if err := b.checkAccount(status); err != nil {
+		return err
+	}
+
+	_, err = b.p.db.StoreServers(parseDataSources(resources), completeStatus(resources))
+	return err
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/daemon"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonBackend_ChecksReadyEveryCommand(t *testing.T) {
+	// The listener went away after the daemon started
+	exe := fakeProgram(t, "sdm", `state="$(dirname "$0")"
+case "$1" in
+ready)
+	echo . >> "$state/ready"
+	echo '{"account":"me@example.com","listener_running":true,"state_loaded":true}' ;;
+status)
+	echo "Connection refused"
+	exit 1 ;;
+esac`)
+
+	retryPolicy, err := sdm.NewRetryPolicy(sdm.RetryConfig{MaxAttempts: 1})
+	require.NoError(t, err)
+
+	p := &App{
+		account:     "me@example.com",
+		sdmWrapper:  *sdm.NewSDMClient(exe),
+		context:     context.Background(),
+		timeout:     5 * time.Second,
+		retryPolicy: retryPolicy,
+	}
+	readyChecks := func() int {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(exe), "ready"))
+		require.NoError(t, err)
+		return strings.Count(string(data), ".")
+	}
+
+	backend := p.DaemonBackend()
+
+	_, err = backend.Status(context.Background())
+	assert.Error(t, err)
+	checks := readyChecks()
+
+	_, err = backend.Status(context.Background())
+	assert.Error(t, err)
+	assert.Greater(t, readyChecks(), checks, "the listener should be checked before every command")
+}
+
+func TestDaemonBackend_OtherAccountLoggedIn(t *testing.T) {
+	// The user switched to another profile while the daemon of me@example.com runs
+	exe := fakeProgram(t, "sdm", `state="$(dirname "$0")"
+case "$1" in
+ready)
+	echo '{"account":"other@example.com","listener_running":true,"state_loaded":true}' ;;
+status)
+	echo '[{"id":"rs-9","name":"other-db","type":"postgres","address":"localhost:10009","connection_status":"not connected","tags":""}]' ;;
+logout)
+	touch "$state/logged-out" ;;
+esac`)
+
+	db, err := storage.NewStorage("me@example.com", t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.StoreServers([]storage.DataSource{{Name: "payments-db", Address: "localhost:10001"}}, true)
+	require.NoError(t, err)
+	require.NoError(t, db.SetPinned("payments-db", true))
+
+	retryPolicy, err := sdm.NewRetryPolicy(sdm.RetryConfig{MaxAttempts: 1})
+	require.NoError(t, err)
+
+	p := &App{
+		account:     "me@example.com",
+		db:          db,
+		sdmWrapper:  *sdm.NewSDMClient(exe),
+		context:     context.Background(),
+		timeout:     5 * time.Second,
+		retryPolicy: retryPolicy,
+	}
+	backend := p.DaemonBackend()
+
+	err = daemon.NewServer("me@example.com", backend).Refresh(context.Background())
+	assert.ErrorIs(t, err, ErrAccountMismatch, "the resources of another account should not be refreshed")
+
+	// The account may also change between the status and the store
+	err = backend.Store([]sdm.Resource{{Name: "other-db", Address: "localhost:10009"}})
+	assert.ErrorIs(t, err, ErrAccountMismatch)
+
+	payments, err := db.GetDatasource("payments-db")
+	require.NoError(t, err, "the cache of the account should be kept")
+	assert.True(t, payments.Pinned)
+	_, err = db.GetDatasource("other-db")
+	assert.ErrorIs(t, err, storage.ErrDataSourceNotFound)
+
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(exe), "logged-out"), "the daemon should never log out")
+}
@@ -19,13 +19,24 @@ func (app *App) mustHaveDependencies() error {
 	requiredDeps := []string{"sdm"}
 
 	// Add password command dependency if needed
-	if app.passwordCommand == PasswordCommandZenity {
-		requiredDeps = append(requiredDeps, "zenity")
+	switch app.passwordCommand {
+	case PasswordCommandZenity, PasswordCommandPinentry, PasswordCommandRofi, PasswordCommandWofi:
+		requiredDeps = append(requiredDeps, app.passwordCommand.String())
+	case PasswordCommandAskpass:
+		helper, err := askpassHelper()
+		if err != nil {
+			return err
+		}
+		requiredDeps = append(requiredDeps, helper)
 	}
 
-	// Add dmenu command dependency if needed
-	if app.dmenuCommand != DMenuCommandNoop {
-		requiredDeps = append(requiredDeps, app.dmenuCommand.String())
+	// Add launcher dependency if needed
+	if app.dmenuCommand != DMenuCommandNoop && app.dmenuCommand != DMenuCommandFzf {
+		launcher, err := app.launcher()
+		if err != nil {
+			return err
+		}
+		requiredDeps = append(requiredDeps, launcher.Name())
 	}
 
 	// Check for each dependency
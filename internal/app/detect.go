@@ -0,0 +1,149 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+)
+
+// ErrNoLauncher is returned when no launcher can be found for the session
+var ErrNoLauncher = errors.New("no launcher found")
+
+// Default launcher preferences, best first
+var (
+	DefaultWaylandLaunchers = []DMenuCommand{DMenuCommandFuzzel, DMenuCommandWofi, DMenuCommandTofi, DMenuCommandWalker, DMenuCommandRofi, DMenuCommandBemenu}
+	DefaultX11Launchers     = []DMenuCommand{DMenuCommandRofi, DMenuCommandDmenu, DMenuCommandBemenu}
+)
+
+// waylandDesktops are desktops that only run on Wayland, used when the display variables are missing
+var waylandDesktops = []string{"hyprland", "sway", "river", "niri", "wayfire", "labwc"}
+
+// session describes the graphical session sdm-ui runs in
+type session struct {
+	Wayland bool
+	X11     bool
+	Desktop string
+}
+
+// currentSession inspects the environment to find the graphical session
+func currentSession() session {
+	s := session{
+		Wayland: os.Getenv("WAYLAND_DISPLAY") != "",
+		X11:     os.Getenv("DISPLAY") != "",
+		Desktop: os.Getenv("XDG_CURRENT_DESKTOP"),
+	}
+
+	// Services started before the session environment is imported only see the desktop name
+	if !s.Wayland && !s.X11 && s.Desktop != "" {
+		desktop := strings.ToLower(s.Desktop)
+		s.Wayland = slices.ContainsFunc(waylandDesktops, func(d string) bool { return strings.Contains(desktop, d) })
+		s.X11 = !s.Wayland
+	}
+
+	return s
+}
+
+// graphical reports whether a launcher window can be shown
+func (s session) graphical() bool {
+	return s.Wayland || s.X11
+}
+
+// String returns the session type
+func (s session) String() string {
+	switch {
+	case s.Wayland:
+		return "wayland"
+	case s.X11:
+		return "x11"
+	default:
+		return "none"
+	}
+}
+
+// detectLauncher picks the first installed launcher from the preference list, falling back to
+// the session defaults when the list is empty and to fzf when running in a terminal without
+// a graphical session
+func detectLauncher(s session, preference []DMenuCommand, lookPath func(string) (string, error), interactive bool) (DMenuCommand, error) {
+	if s.graphical() {
+		if len(preference) == 0 {
+			preference = DefaultX11Launchers
+			if s.Wayland {
+				preference = DefaultWaylandLaunchers
+			}
+		}
+
+		for _, command := range preference {
+			launcher, ok := launchers[command]
+			if !ok {
+				continue
+			}
+			if _, err := lookPath(launcher.Name()); err == nil {
+				return command, nil
+			}
+		}
+	}
+
+	if interactive {
+		return DMenuCommandFzf, nil
+	}
+
+	names := make([]string, 0, len(preference))
+	for _, command := range preference {
+		names = append(names, command.String())
+	}
+	if !s.graphical() {
+		return "", fmt.Errorf("%w: no graphical session and no terminal", ErrNoLauncher)
+	}
+	return "", fmt.Errorf("%w: install one of %s", ErrNoLauncher, strings.Join(names, ", "))
+}
+
+// resolveLauncher replaces the auto launcher with the one detected for the current session
+func (p *App) resolveLauncher() error {
+	if p.dmenuCommand != DMenuCommandAuto {
+		return nil
+	}
+
+	s := currentSession()
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+
+	command, err := detectLauncher(s, p.launcherPreference, exec.LookPath, interactive)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().
+		Str("session", s.String()).
+		Str("desktop", s.Desktop).
+		Bool("terminal", interactive).
+		Str("launcher", command.String()).
+		Msg("Detected launcher")
+
+	p.dmenuCommand = command
+
+	// Without a graphical session the password has to be asked in the terminal as well
+	if command == DMenuCommandFzf && p.passwordCommand == PasswordCommandZenity {
+		log.Debug().Msg("Asking for the password in the terminal")
+		p.passwordCommand = PasswordCommandCLI
+	}
+
+	return nil
+}
+
+// parseLauncherPreference validates the launchers of a preference list
+func parseLauncherPreference(names []string) ([]DMenuCommand, error) {
+	preference := make([]DMenuCommand, 0, len(names))
+	for _, name := range names {
+		command := DMenuCommand(name)
+		if _, ok := launchers[command]; !ok {
+			return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownLauncher, name, strings.Join(Launchers(), ", "))
+		}
+		preference = append(preference, command)
+	}
+	return preference, nil
+}
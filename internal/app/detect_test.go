@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLauncher(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	tests := []struct {
+		name        string
+		session     session
+		preference  []DMenuCommand
+		installed   []string
+		interactive bool
+		expected    DMenuCommand
+		wantErr     bool
+	}{
+		{"WaylandPrefersFuzzel", session{Wayland: true}, nil, []string{"rofi", "wofi", "fuzzel"}, false, DMenuCommandFuzzel, false},
+		{"WaylandFallsBackToRofi", session{Wayland: true}, nil, []string{"rofi"}, false, DMenuCommandRofi, false},
+		{"X11PrefersRofi", session{X11: true}, nil, []string{"dmenu", "rofi"}, false, DMenuCommandRofi, false},
+		{"X11IgnoresWaylandLaunchers", session{X11: true}, nil, []string{"fuzzel", "dmenu"}, false, DMenuCommandDmenu, false},
+		{"ConfiguredPreference", session{X11: true}, []DMenuCommand{DMenuCommandBemenu, DMenuCommandRofi}, []string{"rofi", "bemenu"}, false, DMenuCommandBemenu, false},
+		{"TerminalWithoutSession", session{}, nil, []string{"rofi"}, true, DMenuCommandFzf, false},
+		{"TerminalWithoutLauncher", session{Wayland: true}, nil, nil, true, DMenuCommandFzf, false},
+		{"NothingAvailable", session{Wayland: true}, nil, nil, false, "", true},
+		{"NoSessionNoTerminal", session{}, nil, []string{"rofi"}, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := detectLauncher(tt.session, tt.preference, installed(tt.installed...), tt.interactive)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNoLauncher)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, command)
+		})
+	}
+}
+
+func TestCurrentSession(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("XDG_CURRENT_DESKTOP", "sway")
+	assert.Equal(t, "wayland", currentSession().String())
+
+	t.Setenv("XDG_CURRENT_DESKTOP", "XFCE")
+	assert.Equal(t, "x11", currentSession().String())
+
+	t.Setenv("XDG_CURRENT_DESKTOP", "")
+	assert.Equal(t, "none", currentSession().String())
+
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "wayland-1")
+	assert.Equal(t, "wayland", currentSession().String())
+}
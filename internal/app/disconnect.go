@@ -0,0 +1,109 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// Disconnect disconnects from the data source with the given name or alias
+func (p *App) Disconnect(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty data source name", ErrResourceNotFound)
+	}
+
+	ds, err := p.lookupDataSource(name)
+	if err != nil {
+		// The cache may be stale, let sdm decide whether the resource exists
+		log.Debug().
+			Err(err).
+			Str("datasource", name).
+			Msg("Data source not found in cache, disconnecting by name")
+		ds = storage.DataSource{Name: name}
+	}
+
+	return p.disconnectDataSource(ds)
+}
+
+// DisconnectAll disconnects from every connected data source
+func (p *App) DisconnectAll() error {
+	log.Debug().Msg("Disconnecting from all data sources")
+
+	if err := p.RetryCommand(func() error {
+		return p.sdmWrapper.DisconnectAllWithContext(p.context)
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to disconnect from all data sources")
+		return err
+	}
+
+	p.notify("🔌 All Data Sources Disconnected", "")
+
+	log.Debug().Msg("Syncing data sources after disconnection")
+	if err := p.Sync(); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync data sources after disconnection")
+	}
+
+	return nil
+}
+
+// disconnect disconnects from the data source, logging in again if needed
+func (p *App) disconnect(ds storage.DataSource) error {
+	log.Debug().Str("name", ds.Name).Msg("Disconnecting from data source")
+
+	if err := p.RetryCommand(func() error {
+		return p.sdmDisconnect(ds.Name)
+	}); err != nil {
+		log.Error().
+			Err(err).
+			Str("datasource", ds.Name).
+			Msg("Failed to disconnect from data source")
+		return err
+	}
+
+	log.Debug().Str("name", ds.Name).Msg("Successfully disconnected from data source")
+	return nil
+}
+
+// disconnectDataSource disconnects from the data source, notifies the user and refreshes the cache
+func (p *App) disconnectDataSource(ds storage.DataSource) error {
+	if err := p.disconnect(ds); err != nil {
+		return err
+	}
+
+	p.notify("🔌 Data Source Disconnected", ds.Name)
+
+	log.Debug().Msg("Syncing data sources after disconnection")
+	if err := p.Sync(); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync data sources after disconnection")
+	}
+
+	return nil
+}
+
+// disconnectDataSources disconnects from the data sources, notifies the user once and
+// refreshes the cache
+func (p *App) disconnectDataSources(dataSources []storage.DataSource) error {
+	var errs []error
+	var disconnected []string
+	for _, ds := range dataSources {
+		if err := p.disconnect(ds); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ds.Name, err))
+			continue
+		}
+		disconnected = append(disconnected, ds.Name)
+	}
+
+	if len(disconnected) > 0 {
+		p.notify("🔌 Data Sources Disconnected", strings.Join(disconnected, "\n"))
+	}
+
+	log.Debug().Msg("Syncing data sources after disconnection")
+	if err := p.Sync(); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync data sources after disconnection")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySelectionActionToAll_Disconnect(t *testing.T) {
+	names := []string{"payments-db", "orders-db", "broken-db", "grafana"}
+	p := newFakeSDMApp(t, names...)
+	p.selectionAction = SelectionActionDisconnect
+
+	dataSources := make([]storage.DataSource, 0, len(names))
+	for _, name := range names {
+		dataSources = append(dataSources, storage.DataSource{Name: name, Status: "connected"})
+	}
+
+	err := p.applySelectionActionToAll(dataSources)
+	assert.ErrorContains(t, err, "broken-db", "the failed disconnections should be reported")
+	assert.NotContains(t, err.Error(), "payments-db")
+
+	assert.Equal(t, 1, fakeSDMStatuses(t, p), "the cache should be synced once for the whole selection")
+}
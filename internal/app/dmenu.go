@@ -8,10 +8,8 @@ import (
 	"strings"
 	"time"
 
-	"git.sr.ht/~marianozunino/go-rofi/dmenu"
 	"git.sr.ht/~marianozunino/go-rofi/entry"
 	"github.com/marianozunino/sdm-ui/internal/storage"
-	"github.com/martinlindhe/notify"
 	"github.com/rs/zerolog/log"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/zyedidia/clipper"
@@ -25,9 +23,16 @@ type DMenuCommand string
 
 // Available menu commands
 const (
-	DMenuCommandRofi DMenuCommand = "rofi"
-	DMenuCommandWofi              = "wofi"
-	DMenuCommandNoop              = "noop"
+	DMenuCommandRofi   DMenuCommand = "rofi"
+	DMenuCommandWofi   DMenuCommand = "wofi"
+	DMenuCommandFuzzel DMenuCommand = "fuzzel"
+	DMenuCommandTofi   DMenuCommand = "tofi"
+	DMenuCommandBemenu DMenuCommand = "bemenu"
+	DMenuCommandWalker DMenuCommand = "walker"
+	DMenuCommandDmenu  DMenuCommand = "dmenu"
+	DMenuCommandNoop   DMenuCommand = "noop"
+	DMenuCommandAuto   DMenuCommand = "auto" // Detected from the session when the app starts
+	DMenuCommandFzf    DMenuCommand = "fzf"  // Terminal fallback of the auto launcher
 )
 
 // String returns the string representation of the menu command
@@ -39,31 +44,82 @@ func (d DMenuCommand) String() string {
 func (p *App) DMenu() error {
 	log.Debug().Str("command", p.dmenuCommand.String()).Msg("Starting dmenu interface")
 
-	// Get data sources
-	bytesOut := new(bytes.Buffer)
-	if err := p.List(bytesOut, false); err != nil {
-		log.Error().Err(err).Msg("Failed to list data sources")
-		return err
+	if p.dmenuCommand == DMenuCommandFzf {
+		return p.Fzf()
 	}
 
-	// Create entries for dmenu
-	entries := p.createEntriesFromBuffer(bytesOut)
-	log.Debug().Int("entries", len(entries)).Msg("Created entries for dmenu")
+	for {
+		// Get data sources
+		dataSources, err := p.menuDataSources()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list data sources")
+			return err
+		}
 
-	// Get selection from dmenu
-	selectedEntry, err := p.getSelectionFromDmenu(entries)
-	if err != nil {
-		if errors.Is(err, ErrNoSelection) {
-			log.Debug().Msg("No selection made in dmenu")
+		// Create entries for dmenu, one per data source and in the same order
+		entries, opts := p.menuRows(dataSources)
+		log.Debug().Int("entries", len(entries)).Msg("Created entries for dmenu")
+		if len(entries) != len(dataSources) {
+			return fmt.Errorf("rendered %d menu entries for %d data sources", len(entries), len(dataSources))
+		}
+		opts.Keybindings = p.rofiKeybindings()
+
+		// Get selection from dmenu
+		idx, slot, err := p.menuSelect(p.selectionAction.prompt(), entries, opts)
+		if err != nil {
+			if errors.Is(err, ErrNoSelection) {
+				log.Debug().Msg("No selection made in dmenu")
+				return nil
+			}
+			log.Error().Err(err).Msg("Failed to get selection from dmenu")
+			return err
+		}
+
+		ds := dataSources[idx]
+		log.Debug().
+			Str("name", ds.Name).
+			Str("status", ds.Status).
+			Msg("Data source selected")
+
+		// Run the action bound to the custom key, showing the menu again when it asks to
+		if slot != 0 {
+			action, ok := p.keyActionForSlot(slot)
+			if !ok {
+				return fmt.Errorf("%w: no action bound to kb-custom-%d", ErrInvalidKeybinding, slot)
+			}
+			reopen, err := p.runKeyAction(action, ds)
+			if err != nil {
+				log.Error().Err(err).Str("action", action.String()).Str("datasource", ds.Name).Msg("Key action failed")
+				return err
+			}
+			if reopen {
+				continue
+			}
 			return nil
 		}
-		log.Error().Err(err).Msg("Failed to get selection from dmenu")
-		return err
+
+		// Pick an action for the entry, going back to the list when the action menu is dismissed
+		if p.actionMenu {
+			err := p.showActionMenu(ds)
+			if errors.Is(err, ErrNoSelection) {
+				continue
+			}
+			return err
+		}
+
+		return p.applySelectionAction(ds)
+	}
+}
+
+// menuRows renders the data sources as menu entries, using rich rows when rofi can display them
+func (p *App) menuRows(dataSources []storage.DataSource) ([]*entry.Entry, menuOptions) {
+	if p.dmenuCommand == DMenuCommandRofi {
+		return p.rofiRows(dataSources)
 	}
 
-	// Handle the selected entry
-	log.Debug().Str("selection", selectedEntry).Msg("Handling selected entry")
-	return p.handleSelectedEntry(selectedEntry)
+	bytesOut := new(bytes.Buffer)
+	p.PrintDataSources(dataSources, bytesOut, false)
+	return p.createEntriesFromBuffer(bytesOut), menuOptions{}
 }
 
 // createEntriesFromBuffer converts buffer lines to entry objects
@@ -86,20 +142,14 @@ func (p *App) createEntriesFromBuffer(buf *bytes.Buffer) []*entry.Entry {
 	return entries
 }
 
-// getSelectionFromDmenu displays dmenu and returns the selected entry
-func (p *App) getSelectionFromDmenu(entries []*entry.Entry) (string, error) {
+// menuSelect displays the entries in the configured launcher and returns the index of the selected
+// entry along with the rofi custom key slot used to select it, or 0 for a regular selection.
+func (p *App) menuSelect(prompt string, entries []*entry.Entry, opts menuOptions) (int, int, error) {
 	if len(entries) == 0 {
 		log.Warn().Msg("No entries to display in dmenu")
-		return "", ErrNoSelection
+		return 0, 0, ErrNoSelection
 	}
 
-	// Create dmenu instance
-	d := dmenu.New(
-		dmenu.WithPrompt("Select Data Source"),
-		dmenu.WithEntries(entries...),
-		dmenu.WithExecPath(string(p.dmenuCommand)),
-	)
-
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
@@ -109,134 +159,115 @@ func (p *App) getSelectionFromDmenu(entries []*entry.Entry) (string, error) {
 		Int("entries", len(entries)).
 		Msg("Displaying dmenu")
 
-	// Get selection
-	s, err := d.Select(ctx)
+	launcher, err := p.launcher()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	idx, slot, err := launcher.Select(ctx, prompt, entries, opts)
 	if err != nil {
-		if strings.Contains(err.Error(), "exit status 1") {
-			// User canceled dmenu
-			log.Debug().Msg("User canceled dmenu selection")
-			return "", ErrNoSelection
+		if !errors.Is(err, ErrNoSelection) {
+			log.Error().Err(err).Msg("Error during dmenu selection")
 		}
-		log.Error().Err(err).Msg("Error during dmenu selection")
-		return "", err
+		return 0, 0, err
 	}
 
-	log.Debug().Str("selection", s).Msg("Selection made in dmenu")
-	return s, nil
+	log.Debug().Int("index", idx).Int("slot", slot).Msg("Selection made in dmenu")
+	return idx, slot, nil
 }
 
-// handleSelectedEntry processes the selected entry from dmenu
-func (p *App) handleSelectedEntry(selectedEntry string) error {
-	// Parse the selected entry
-	fields := strings.Fields(selectedEntry)
-	if len(fields) < 2 {
-		log.Warn().
-			Str("selection", selectedEntry).
-			Msg("Invalid selection: not enough fields")
-		notify.Notify("SDM CLI", "🔐 Resource not found", "", "")
-		return nil
-	}
-
-	// Get the data source name
-	selectedDS := fields[0]
-	log.Debug().Str("datasource", selectedDS).Msg("Selected data source")
+// entryText returns the text displayed for an entry, without its row options
+func entryText(e *entry.Entry) string {
+	text, _, _ := strings.Cut(e.Build(), "\x00")
+	return strings.TrimSpace(text)
+}
 
-	if selectedDS == "" {
-		log.Warn().Msg("Empty data source name")
-		notify.Notify("SDM CLI", "🔐 Resource not found", "", "")
-		return nil
-	}
+// notifyDataSourceConnected notifies the user of a successful connection
+func (p *App) notifyDataSourceConnected(ds storage.DataSource) {
+	title := "🔌 Data Source Connected"
+	message := fmt.Sprintf("%s\n📋 <b>%s</b>", ds.Name, ds.Address)
 
-	// Get the data source from the database
-	ds, err := p.db.GetDatasource(selectedDS)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Str("datasource", selectedDS).
-			Msg("Failed to get data source from database")
-		notify.Notify("SDM CLI", "🔐 Resource not found", "", "")
-		return nil
+	// Handle web URLs by opening browser
+	if isWebAddress(ds.Address) {
+		openInBrowser(ds.Address)
+	} else {
+		copyToClipboard(ds.Address)
 	}
 
-	// Connect to the data source
+	// Show desktop notification
+	p.notify(title, message)
 	log.Debug().
 		Str("name", ds.Name).
 		Str("address", ds.Address).
-		Msg("Connecting to data source")
-
-	if err := p.RetryCommand(func() error {
-		// Update last used timestamp
-		if err := p.db.UpdateLastUsed(ds); err != nil {
-			log.Warn().
-				Err(err).
-				Str("datasource", ds.Name).
-				Msg("Failed to update last used timestamp")
+		Msg("Data source connected notification sent")
+}
+
+// notifyDataSourcesConnected sends one notification summarizing several connections.
+// Addresses of the connected data sources are copied to the clipboard, one per line,
+// and web URLs are opened in the browser.
+func (p *App) notifyDataSourcesConnected(results []ConnectResult) {
+	var addresses, lines []string
+	connected := 0
+
+	for _, result := range results {
+		ds := result.DataSource
+		if result.Err != nil {
+			lines = append(lines, fmt.Sprintf("✘ %s: %v", ds.Name, result.Err))
+			continue
 		}
 
-		// Connect to data source
-		return p.sdmWrapper.Connect(ds.Name)
-	}); err != nil {
-		log.Error().
-			Err(err).
-			Str("datasource", ds.Name).
-			Msg("Failed to connect to data source")
-		return err
+		connected++
+		lines = append(lines, fmt.Sprintf("✔ %s <b>%s</b>", ds.Name, ds.Address))
+		if isWebAddress(ds.Address) {
+			openInBrowser(ds.Address)
+		} else if ds.Address != "" {
+			addresses = append(addresses, ds.Address)
+		}
+	}
+
+	if len(addresses) > 0 {
+		copyToClipboard(strings.Join(addresses, "\n"))
 	}
 
-	// Notify user of successful connection
+	title := fmt.Sprintf("🔌 Connected to %d of %d Data Sources", connected, len(results))
+	p.notify(title, strings.Join(lines, "\n"))
 	log.Debug().
-		Str("name", ds.Name).
-		Msg("Successfully connected to data source")
-	p.notifyDataSourceConnected(ds)
+		Int("connected", connected).
+		Int("failed", len(results)-connected).
+		Msg("Data sources connected notification sent")
+}
 
-	// Sync data sources
-	log.Debug().Msg("Syncing data sources after connection")
-	if err := p.Sync(); err != nil {
+// isWebAddress reports whether the address should be opened in a browser
+func isWebAddress(address string) bool {
+	return strings.HasPrefix(address, "http")
+}
+
+// openInBrowser opens the URL in the default browser
+func openInBrowser(url string) {
+	log.Debug().
+		Str("url", url).
+		Msg("Opening URL in browser")
+	if err := open.Start(url); err != nil {
 		log.Warn().
 			Err(err).
-			Msg("Failed to sync data sources after connection")
+			Str("url", url).
+			Msg("Failed to open URL in browser")
 	}
-
-	return nil
 }
 
-// notifyDataSourceConnected notifies the user of a successful connection
-func (p *App) notifyDataSourceConnected(ds storage.DataSource) {
-	title := "🔌 Data Source Connected"
-	message := fmt.Sprintf("%s\n📋 <b>%s</b>", ds.Name, ds.Address)
-
-	// Handle web URLs by opening browser
-	if strings.HasPrefix(ds.Address, "http") {
-		log.Debug().
-			Str("url", ds.Address).
-			Msg("Opening URL in browser")
-		if err := open.Start(ds.Address); err != nil {
-			log.Warn().
-				Err(err).
-				Str("url", ds.Address).
-				Msg("Failed to open URL in browser")
-		}
-	} else {
-		// Copy address to clipboard
-		log.Debug().Msg("Copying address to clipboard")
-		clip, err := clipper.GetClipboard(clipper.Clipboards...)
-		if err != nil {
-			log.Warn().
-				Err(err).
-				Msg("Failed to get clipboard")
-		} else {
-			if err := clip.WriteAll(clipper.RegClipboard, []byte(ds.Address)); err != nil {
-				log.Warn().
-					Err(err).
-					Msg("Failed to write to clipboard")
-			}
-		}
+// copyToClipboard writes the text to the system clipboard
+func copyToClipboard(text string) {
+	log.Debug().Msg("Copying address to clipboard")
+	clip, err := clipper.GetClipboard(clipper.Clipboards...)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Failed to get clipboard")
+		return
+	}
+	if err := clip.WriteAll(clipper.RegClipboard, []byte(text)); err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Failed to write to clipboard")
 	}
-
-	// Show desktop notification
-	notify.Notify("SDM CLI", title, message, "")
-	log.Debug().
-		Str("name", ds.Name).
-		Str("address", ds.Address).
-		Msg("Data source connected notification sent")
 }
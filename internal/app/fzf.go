@@ -1,7 +1,13 @@
 package app
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/marianozunino/sdm-ui/internal/storage"
 	"github.com/rs/zerolog/log"
 )
 
@@ -10,7 +16,7 @@ func (p *App) Fzf() error {
 	log.Debug().Msg("Starting fuzzy finder interface")
 
 	// Get data sources
-	dataSources, err := p.GetSortedDataSources()
+	dataSources, err := p.menuDataSources()
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to retrieve data sources")
 		return err
@@ -31,8 +37,22 @@ func (p *App) Fzf() error {
 	idx, err := fuzzyfinder.FindMulti(
 		dataSources,
 		func(i int) string {
-			return formatStatus(dataSources[i].Status) + " " + dataSources[i].Name
+			status := formatStatus(dataSources[i].Status)
+			if dataSources[i].Pinned {
+				status = "📌" + status
+			}
+			if dataSources[i].Alias != "" {
+				return status + " " + dataSources[i].Alias + " → " + dataSources[i].Name
+			}
+			return status + " " + dataSources[i].Name
 		},
+		fuzzyfinder.WithPromptString(p.selectionAction.prompt()+"> "),
+		fuzzyfinder.WithPreviewWindow(func(i, width, height int) string {
+			if i == -1 {
+				return ""
+			}
+			return previewDataSource(dataSources[i], previewWidth(width), time.Now())
+		}),
 	)
 	// Handle selection error
 	if err != nil {
@@ -46,37 +66,116 @@ func (p *App) Fzf() error {
 		return nil
 	}
 
-	// Get selected data source
-	selectedDS := dataSources[idx[0]]
-	log.Debug().
-		Str("name", selectedDS.Name).
-		Str("status", selectedDS.Status).
-		Msg("Data source selected")
+	// Get selected data sources
+	selected := make([]storage.DataSource, 0, len(idx))
+	for _, i := range idx {
+		log.Debug().
+			Str("name", dataSources[i].Name).
+			Str("status", dataSources[i].Status).
+			Msg("Data source selected")
+		selected = append(selected, dataSources[i])
+	}
+
+	return p.applySelectionActionToAll(selected)
+}
 
-	// Connect to selected data source
-	log.Debug().Str("name", selectedDS.Name).Msg("Connecting to selected data source")
-	if err := p.RetryCommand(func() error {
-		// Update last used timestamp
-		if err := p.db.UpdateLastUsed(selectedDS); err != nil {
-			log.Warn().Err(err).Str("name", selectedDS.Name).Msg("Failed to update last used timestamp")
+// previewWidth returns the number of columns available for text in the preview pane,
+// which takes the right half of the terminal minus its borders and padding
+func previewWidth(width int) int {
+	return width/2 - 4
+}
+
+// previewDataSource renders every detail of the data source for the fzf preview pane.
+// Values are wrapped to the given width since the pane cuts long lines.
+func previewDataSource(ds storage.DataSource, width int, now time.Time) string {
+	var b strings.Builder
+	field := func(label, value string) {
+		const labelWidth = 13
+		for i, line := range wrap(value, width-labelWidth) {
+			if i == 0 {
+				fmt.Fprintf(&b, "%-*s%s\n", labelWidth, label+":", line)
+				continue
+			}
+			fmt.Fprintf(&b, "%-*s%s\n", labelWidth, "", line)
 		}
+	}
 
-		// Connect to data source
-		return p.sdmWrapper.Connect(selectedDS.Name)
-	}); err != nil {
-		log.Error().Err(err).Str("name", selectedDS.Name).Msg("Failed to connect to data source")
-		return err
+	field("Name", ds.Name)
+	if ds.Alias != "" {
+		field("Alias", ds.Alias)
+	}
+	field("Status", ds.Status)
+	field("Type", ds.Type)
+	field("Address", ds.Address)
+	if ds.WebURL != "" {
+		field("Web URL", ds.WebURL)
+	}
+	field("Last used", formatLastUsed(ds.LRU, now))
+	field("Connections", fmt.Sprint(ds.UseCount))
+	if ds.Pinned {
+		field("Pinned", "yes")
 	}
 
-	// Notify user of successful connection
-	log.Debug().Str("name", selectedDS.Name).Msg("Successfully connected to data source")
-	p.notifyDataSourceConnected(selectedDS)
+	if len(ds.Tags) == 0 {
+		field("Tags", "-")
+		return b.String()
+	}
 
-	// Sync data sources
-	log.Debug().Msg("Syncing data sources after connection")
-	if err := p.Sync(); err != nil {
-		log.Warn().Err(err).Msg("Failed to sync data sources after connection")
+	keys := make([]string, 0, len(ds.Tags))
+	for key := range ds.Tags {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	return nil
+	b.WriteString("Tags:\n")
+	for _, key := range keys {
+		tag := key
+		if value := ds.Tags[key]; value != "" {
+			tag += "=" + value
+		}
+		for _, line := range wrap(tag, width-2) {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// formatLastUsed formats a last used Unix timestamp along with the time elapsed since then
+func formatLastUsed(lru int64, now time.Time) string {
+	if lru == 0 {
+		return "never"
+	}
+
+	usedAt := time.Unix(lru, 0)
+	elapsed := now.Sub(usedAt)
+
+	var ago string
+	switch {
+	case elapsed < time.Minute:
+		ago = "just now"
+	case elapsed < time.Hour:
+		ago = fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		ago = fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	default:
+		ago = fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	}
+
+	return usedAt.Format("2006-01-02 15:04") + " (" + ago + ")"
+}
+
+// wrap splits the text into lines of at most width runes
+func wrap(text string, width int) []string {
+	runes := []rune(text)
+	if width <= 0 || len(runes) <= width {
+		return []string{text}
+	}
+
+	lines := make([]string, 0, len(runes)/width+1)
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
 }
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewDataSource(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+
+	ds := storage.DataSource{
+		Name:     "payments-db-prod",
+		Status:   "connected",
+		Type:     "postgres",
+		Address:  "localhost:10001",
+		WebURL:   "https://app.strongdm.com/resources/rs-1",
+		Tags:     map[string]string{"team": "payments", "env": "prod", "critical": ""},
+		LRU:      now.Add(-3 * time.Hour).Unix(),
+		UseCount: 7,
+	}
+
+	preview := previewDataSource(ds, 80, now)
+
+	assert.Contains(t, preview, "Address:     localhost:10001\n")
+	assert.Contains(t, preview, "Web URL:     https://app.strongdm.com/resources/rs-1\n")
+	assert.Contains(t, preview, "Last used:   2024-05-10 09:00 (3h ago)\n")
+	assert.Contains(t, preview, "Connections: 7\n")
+	assert.Contains(t, preview, "Tags:\n  critical\n  env=prod\n  team=payments\n")
+	assert.NotContains(t, preview, "Alias:")
+}
+
+func TestPreviewDataSource_WrapsLongValues(t *testing.T) {
+	ds := storage.DataSource{
+		Name:    "grafana",
+		Address: "http://grafana.internal.example.com/dashboards",
+	}
+
+	preview := previewDataSource(ds, 32, time.Now())
+
+	assert.Contains(t, preview, "Address:     http://grafana.inte\n")
+	assert.Contains(t, preview, "             rnal.example.com/da\n")
+	assert.Contains(t, preview, "             shboards\n")
+	assert.Contains(t, preview, "Last used:   never\n")
+	assert.Contains(t, preview, "Tags:        -\n")
+}
+
+func TestFormatLastUsed(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		lru      int64
+		expected string
+	}{
+		{"Never", 0, "never"},
+		{"JustNow", now.Add(-10 * time.Second).Unix(), "(just now)"},
+		{"Minutes", now.Add(-5 * time.Minute).Unix(), "(5m ago)"},
+		{"Days", now.Add(-49 * time.Hour).Unix(), "(2d ago)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, formatLastUsed(tt.lru, now), tt.expected)
+		})
+	}
+}
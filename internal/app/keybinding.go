@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidKeybinding is returned when a configured keybinding can't be used
+var ErrInvalidKeybinding = errors.New("invalid keybinding")
+
+// KeyAction is what a rofi custom key does to the highlighted data source
+type KeyAction string
+
+// Available key actions
+const (
+	KeyActionConnect     KeyAction = "connect"
+	KeyActionDisconnect  KeyAction = "disconnect"
+	KeyActionCopyAddress KeyAction = "copy-address"
+	KeyActionOpenURL     KeyAction = "open-url"
+	KeyActionPin         KeyAction = "pin"
+	KeyActionDetails     KeyAction = "details"
+)
+
+// keyActionHints are the descriptions shown in the rofi message bar
+var keyActionHints = map[KeyAction]string{
+	KeyActionConnect:     "connect",
+	KeyActionDisconnect:  "disconnect",
+	KeyActionCopyAddress: "copy address",
+	KeyActionOpenURL:     "open URL",
+	KeyActionPin:         "pin/unpin",
+	KeyActionDetails:     "details",
+}
+
+// String returns the string representation of the key action
+func (a KeyAction) String() string {
+	return string(a)
+}
+
+// Keybinding binds a rofi key combination to an action
+type Keybinding struct {
+	Key    string    `mapstructure:"key"`
+	Action KeyAction `mapstructure:"action"`
+}
+
+// DefaultKeybindings are used when no keybindings are configured
+var DefaultKeybindings = []Keybinding{
+	{Key: "Alt+c", Action: KeyActionConnect},
+	{Key: "Alt+d", Action: KeyActionDisconnect},
+	{Key: "Alt+y", Action: KeyActionCopyAddress},
+	{Key: "Alt+o", Action: KeyActionOpenURL},
+	{Key: "Alt+p", Action: KeyActionPin},
+	{Key: "Alt+i", Action: KeyActionDetails},
+}
+
+// validateKeybindings checks that every keybinding has a key, a known action and fits in a rofi slot
+func validateKeybindings(keybindings []Keybinding) error {
+	maxKeybindings := rofiCustomKeyLastExitCode - rofiCustomKeyFirstExitCode + 1
+	if len(keybindings) > maxKeybindings {
+		return fmt.Errorf("%w: rofi supports at most %d custom keys, got %d", ErrInvalidKeybinding, maxKeybindings, len(keybindings))
+	}
+
+	seen := make(map[string]bool, len(keybindings))
+	for i, kb := range keybindings {
+		if kb.Key == "" {
+			return fmt.Errorf("%w: keybindings[%d] has no key", ErrInvalidKeybinding, i)
+		}
+		if _, ok := keyActionHints[kb.Action]; !ok {
+			return fmt.Errorf("%w: keybindings[%d] has unknown action %q", ErrInvalidKeybinding, i, kb.Action)
+		}
+
+		key := strings.ToLower(kb.Key)
+		if seen[key] {
+			return fmt.Errorf("%w: key %s is bound more than once", ErrInvalidKeybinding, kb.Key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// rofiKeybindings assigns the configured keybindings to rofi's kb-custom slots in order
+func (p *App) rofiKeybindings() []rofiKeybinding {
+	keybindings := make([]rofiKeybinding, 0, len(p.keybindings))
+	for i, kb := range p.keybindings {
+		keybindings = append(keybindings, rofiKeybinding{
+			Slot: i + 1,
+			Key:  kb.Key,
+			Hint: keyActionHints[kb.Action],
+		})
+	}
+	return keybindings
+}
+
+// keyActionForSlot returns the action bound to the rofi kb-custom slot
+func (p *App) keyActionForSlot(slot int) (KeyAction, bool) {
+	if slot < 1 || slot > len(p.keybindings) {
+		return "", false
+	}
+	return p.keybindings[slot-1].Action, true
+}
+
+// runKeyAction runs the action on the data source and reports whether the menu should be shown again
+func (p *App) runKeyAction(action KeyAction, ds storage.DataSource) (bool, error) {
+	log.Debug().
+		Str("action", action.String()).
+		Str("datasource", ds.Name).
+		Msg("Running key action")
+
+	switch action {
+	case KeyActionConnect:
+		return false, p.connectDataSource(ds)
+	case KeyActionDisconnect:
+		return false, p.disconnectDataSource(ds)
+	case KeyActionCopyAddress:
+		copyToClipboard(ds.Address)
+		p.notify("📋 Address Copied", fmt.Sprintf("%s\n<b>%s</b>", ds.Name, ds.Address))
+		return false, nil
+	case KeyActionOpenURL:
+		url := webURL(ds)
+		if url == "" {
+			p.notify("🌐 No web URL", ds.Name)
+			return false, nil
+		}
+		openInBrowser(url)
+		return false, nil
+	case KeyActionPin:
+		return true, p.togglePin(ds)
+	case KeyActionDetails:
+		return true, p.showDetails(ds)
+	default:
+		return false, fmt.Errorf("%w: unknown action %q", ErrInvalidKeybinding, action)
+	}
+}
+
+// showDetails displays every detail of the data source in rofi until it is dismissed
+func (p *App) showDetails(ds storage.DataSource) error {
+	lines := strings.Split(strings.TrimRight(previewDataSource(ds, 0, time.Now()), "\n"), "\n")
+	entries := make([]*entry.Entry, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, entry.New(line))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if _, _, err := rofiSelect(ctx, ds.Name, entries, menuOptions{}); err != nil && !errors.Is(err, ErrNoSelection) {
+		return err
+	}
+	return nil
+}
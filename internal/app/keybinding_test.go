@@ -0,0 +1,51 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKeybindings(t *testing.T) {
+	tooMany := make([]Keybinding, 20)
+	for i := range tooMany {
+		tooMany[i] = Keybinding{Key: "Alt+" + string(rune('a'+i)), Action: KeyActionPin}
+	}
+
+	tests := []struct {
+		name        string
+		keybindings []Keybinding
+		wantErr     bool
+	}{
+		{"Defaults", DefaultKeybindings, false},
+		{"Empty", nil, false},
+		{"MissingKey", []Keybinding{{Action: KeyActionPin}}, true},
+		{"UnknownAction", []Keybinding{{Key: "Alt+x", Action: "explode"}}, true},
+		{"DuplicateKey", []Keybinding{{Key: "Alt+p", Action: KeyActionPin}, {Key: "alt+p", Action: KeyActionDetails}}, true},
+		{"TooMany", tooMany, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeybindings(tt.keybindings)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidKeybinding)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyActionForSlot(t *testing.T) {
+	p := &App{keybindings: DefaultKeybindings}
+
+	for i, kb := range p.rofiKeybindings() {
+		action, ok := p.keyActionForSlot(kb.Slot)
+		assert.True(t, ok)
+		assert.Equal(t, DefaultKeybindings[i].Action, action)
+	}
+
+	_, ok := p.keyActionForSlot(len(DefaultKeybindings) + 1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnknownLauncher is returned when the configured launcher is not supported
+var ErrUnknownLauncher = errors.New("unknown launcher")
+
+// Launcher is a dmenu-like program that lists entries and reports the one picked by the user
+type Launcher interface {
+	// Name returns the executable of the launcher
+	Name() string
+	// Select shows the entries and returns the index of the selected one along with the
+	// rofi custom key slot used to select it, or 0 for a regular selection.
+	// Launchers ignore the options they don't support.
+	Select(ctx context.Context, prompt string, entries []*entry.Entry, opts menuOptions) (int, int, error)
+}
+
+// menuOptions are the rofi specific features of a menu
+type menuOptions struct {
+	Keybindings []rofiKeybinding
+	Markup      bool  // Entries use Pango markup
+	Active      []int // Indices of the rows shown as active
+	Urgent      []int // Indices of the rows shown as urgent
+}
+
+// launchers holds every supported launcher, keyed by menu command
+var launchers = map[DMenuCommand]Launcher{
+	DMenuCommandRofi: rofiLauncher{},
+	DMenuCommandWofi: &dmenuLauncher{
+		name:        "wofi",
+		args:        func(prompt string) []string { return []string{"--dmenu", "--prompt", prompt} },
+		cancelCodes: []int{1},
+	},
+	DMenuCommandFuzzel: &dmenuLauncher{
+		name:        "fuzzel",
+		args:        func(prompt string) []string { return []string{"--dmenu", "--prompt", prompt + "> "} },
+		cancelCodes: []int{1, 2},
+	},
+	DMenuCommandTofi: &dmenuLauncher{
+		name:        "tofi",
+		args:        func(prompt string) []string { return []string{"--prompt-text", prompt + ": "} },
+		cancelCodes: []int{1},
+	},
+	DMenuCommandBemenu: &dmenuLauncher{
+		name:        "bemenu",
+		args:        func(prompt string) []string { return []string{"--prompt", prompt, "--ignorecase"} },
+		cancelCodes: []int{1},
+	},
+	DMenuCommandWalker: &dmenuLauncher{
+		name:        "walker",
+		args:        func(prompt string) []string { return []string{"--dmenu", "--placeholder", prompt} },
+		cancelCodes: []int{1},
+	},
+	DMenuCommandDmenu: &dmenuLauncher{
+		name:        "dmenu",
+		args:        func(prompt string) []string { return []string{"-p", prompt, "-i"} },
+		cancelCodes: []int{1},
+	},
+}
+
+// Launchers returns the names of the supported launchers
+func Launchers() []string {
+	names := make([]string, 0, len(launchers))
+	for command := range launchers {
+		names = append(names, command.String())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// launcher returns the launcher for the configured menu command
+func (p *App) launcher() (Launcher, error) {
+	// fzf is the terminal fallback of the auto launcher, not a program that can be preferred
+	if p.dmenuCommand == DMenuCommandFzf {
+		return fzfLauncher{find: fuzzyfinder.Find}, nil
+	}
+
+	launcher, ok := launchers[p.dmenuCommand]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownLauncher, p.dmenuCommand, strings.Join(Launchers(), ", "))
+	}
+	return launcher, nil
+}
+
+// rofiLauncher runs rofi, the only launcher reporting indices and custom keys
+type rofiLauncher struct{}
+
+// Name returns the executable of rofi
+func (rofiLauncher) Name() string {
+	return DMenuCommandRofi.String()
+}
+
+// Select shows the entries in rofi
+func (rofiLauncher) Select(ctx context.Context, prompt string, entries []*entry.Entry, opts menuOptions) (int, int, error) {
+	return rofiSelect(ctx, prompt, entries, opts)
+}
+
+// dmenuLauncher runs a launcher that reads lines on stdin and prints the selected line
+type dmenuLauncher struct {
+	name        string
+	args        func(prompt string) []string
+	cancelCodes []int // Exit codes meaning the user dismissed the menu
+}
+
+// Name returns the executable of the launcher
+func (l *dmenuLauncher) Name() string {
+	return l.name
+}
+
+// Select shows the entries and matches the printed line back to its entry
+func (l *dmenuLauncher) Select(ctx context.Context, prompt string, entries []*entry.Entry, _ menuOptions) (int, int, error) {
+	path, err := exec.LookPath(l.name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Row options are rofi specific, only send the text
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, entryText(e))
+	}
+
+	cmd := exec.CommandContext(ctx, path, l.args(prompt)...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && slices.Contains(l.cancelCodes, exitErr.ExitCode()) {
+			log.Debug().Str("launcher", l.name).Int("code", exitErr.ExitCode()).Msg("User canceled selection")
+			return 0, 0, ErrNoSelection
+		}
+		return 0, 0, fmt.Errorf("%s failed: %w", l.name, err)
+	}
+
+	selection := strings.TrimSpace(string(output))
+	if selection == "" {
+		log.Debug().Str("launcher", l.name).Msg("Empty selection")
+		return 0, 0, ErrNoSelection
+	}
+
+	for i, line := range lines {
+		if line == selection {
+			return i, 0, nil
+		}
+	}
+
+	log.Warn().Str("launcher", l.name).Str("selection", selection).Msg("Selection does not match any entry")
+	return 0, 0, ErrNoSelection
+}
+
+// fzfLauncher shows the entries in the terminal with the builtin fuzzy finder
+type fzfLauncher struct {
+	find func(items any, itemFunc func(i int) string, opts ...fuzzyfinder.Option) (int, error)
+}
+
+// Name returns the name of the fuzzy finder
+func (fzfLauncher) Name() string {
+	return DMenuCommandFzf.String()
+}
+
+// Select shows the entries in the fuzzy finder
+func (l fzfLauncher) Select(ctx context.Context, prompt string, entries []*entry.Entry, _ menuOptions) (int, int, error) {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, entryText(e))
+	}
+
+	idx, err := l.find(lines, func(i int) string { return lines[i] },
+		fuzzyfinder.WithPromptString(prompt+"> "),
+		fuzzyfinder.WithContext(ctx),
+	)
+	if err != nil {
+		if errors.Is(err, fuzzyfinder.ErrAbort) {
+			log.Debug().Msg("User canceled selection")
+			return 0, 0, ErrNoSelection
+		}
+		return 0, 0, fmt.Errorf("fzf failed: %w", err)
+	}
+	return idx, 0, nil
+}
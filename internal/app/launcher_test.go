@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLauncher installs a launcher script running the given shell body in a temporary PATH
+func fakeLauncher(t *testing.T, body string) *dmenuLauncher {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" + body + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fake-launcher"), []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return &dmenuLauncher{
+		name:        "fake-launcher",
+		args:        func(prompt string) []string { return []string{"--prompt", prompt} },
+		cancelCodes: []int{1, 2},
+	}
+}
+
+func TestDmenuLauncher_Select(t *testing.T) {
+	entries := []*entry.Entry{
+		entry.New("payments db\tlocalhost:10001"),
+		entry.New("cache\tlocalhost:10002", entry.WithIcon("redis")),
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		err      error
+		anyErr   bool
+	}{
+		{"SelectsMatchingLine", `sed -n 2p`, 1, nil, false},
+		{"NameWithSpaces", `sed -n 1p`, 0, nil, false},
+		{"CancelCode", `cat >/dev/null; exit 2`, 0, ErrNoSelection, false},
+		{"EmptyOutput", `cat >/dev/null`, 0, ErrNoSelection, false},
+		{"UnknownLine", `echo custom input`, 0, ErrNoSelection, false},
+		{"Failure", `cat >/dev/null; exit 3`, 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			launcher := fakeLauncher(t, tt.body)
+
+			idx, slot, err := launcher.Select(context.Background(), "Select", entries, menuOptions{})
+			switch {
+			case tt.err != nil:
+				assert.ErrorIs(t, err, tt.err)
+			case tt.anyErr:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNoSelection)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, idx)
+				assert.Zero(t, slot)
+			}
+		})
+	}
+}
+
+func TestApp_Launcher(t *testing.T) {
+	for _, name := range Launchers() {
+		p := &App{dmenuCommand: DMenuCommand(name)}
+		launcher, err := p.launcher()
+		require.NoError(t, err)
+		assert.Equal(t, name, launcher.Name())
+	}
+
+	// The auto launcher falls back to fzf in a terminal, e.g. to pick an account
+	command, err := detectLauncher(session{}, nil, exec.LookPath, true)
+	require.NoError(t, err)
+	p := &App{dmenuCommand: command}
+	launcher, err := p.launcher()
+	require.NoError(t, err)
+	assert.Equal(t, "fzf", launcher.Name())
+
+	p = &App{dmenuCommand: "nope"}
+	_, err = p.launcher()
+	assert.ErrorIs(t, err, ErrUnknownLauncher)
+}
+
+func TestFzfLauncher_Select(t *testing.T) {
+	entries := []*entry.Entry{
+		entry.New("  default\tme@example.com"),
+		entry.New("✔ work\twork@example.com", entry.WithIcon("user")),
+	}
+
+	tests := []struct {
+		name     string
+		find     func(items any, itemFunc func(i int) string, opts ...fuzzyfinder.Option) (int, error)
+		expected int
+		err      error
+	}{
+		{"Selects", func(items any, itemFunc func(i int) string, _ ...fuzzyfinder.Option) (int, error) {
+			// Only the text of the entries is shown
+			assert.Equal(t, "✔ work\twork@example.com", itemFunc(1))
+			return 1, nil
+		}, 1, nil},
+		{"Aborted", func(any, func(i int) string, ...fuzzyfinder.Option) (int, error) {
+			return 0, fuzzyfinder.ErrAbort
+		}, 0, ErrNoSelection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, slot, err := fzfLauncher{find: tt.find}.Select(context.Background(), "Account", entries, menuOptions{})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, idx)
+			assert.Zero(t, slot)
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"fmt"
 	"io"
-	"regexp"
-	"slices"
+	"text/tabwriter"
+	"time"
 
+	"github.com/marianozunino/sdm-ui/internal/filter"
 	"github.com/marianozunino/sdm-ui/internal/storage"
 	"github.com/rs/zerolog/log"
 )
@@ -21,45 +23,86 @@ func (p *App) List(w io.Writer, withHeader bool) error {
 	return nil
 }
 
-func (p *App) applyBlacklist(dataSources []storage.DataSource) []storage.DataSource {
-	if len(p.blacklistPatterns) == 0 {
-		return dataSources
+// ExplainFilter writes every cached data source along with the rule that shows or hides it
+func (p *App) ExplainFilter(w io.Writer) error {
+	dataSources, err := p.cachedDataSources()
+	if err != nil {
+		return err
 	}
 
-	log.Debug().
-		Strs("patterns", p.blacklistPatterns).
-		Int("source_count", len(dataSources)).
-		Msg("Applying blacklist patterns")
+	sortDataSources(dataSources, SortName, time.Now())
 
-	filteredDataSources := make([]storage.DataSource, 0, len(dataSources))
-	blacklistedCount := 0
+	const format = "%v\t%v\t%v\n"
+	tw := tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+	fmt.Fprintf(tw, format, "NAME", "VISIBLE", "REASON")
+	fmt.Fprintf(tw, format, "----", "-------", "------")
 
 	for _, ds := range dataSources {
-		blacklisted := false
-		for _, regex := range p.blacklistPatterns {
-			if match, err := regexp.MatchString(regex, ds.Name); match {
-				if err != nil {
-					log.Warn().Err(err).Str("pattern", regex).Msg("Invalid regex pattern")
-				}
-				blacklisted = true
-				blacklistedCount++
-				break
-			}
+		visible, reason := p.explain(ds)
+		verdict := "yes"
+		if !visible {
+			verdict = "no"
 		}
-		if !blacklisted {
-			filteredDataSources = append(filteredDataSources, ds)
+		fmt.Fprintf(tw, format, ds.Name, verdict, reason)
+	}
+
+	return tw.Flush()
+}
+
+// explain reports whether the data source is shown and which rule decided it
+func (p *App) explain(ds storage.DataSource) (bool, string) {
+	decision := p.filter.Evaluate(ds)
+	if !decision.Included {
+		return false, decision.Reason()
+	}
+
+	for _, tagFilter := range p.tagFilters {
+		if !tagFilter.Match(ds.Tags) {
+			return false, "tag filter: " + tagFilter.String()
 		}
 	}
 
+	return true, decision.Reason()
+}
+
+// buildFilter compiles the blacklist patterns and filter rules, blacklist first
+func (p *App) buildFilter() (*filter.Filter, error) {
+	blacklistRules, err := filter.BlacklistRules(p.blacklistPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := filter.CompileRules(p.filterRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.New(append(blacklistRules, rules...)...), nil
+}
+
+func (p *App) applyFilter(dataSources []storage.DataSource) []storage.DataSource {
+	if p.filter.Empty() {
+		return dataSources
+	}
+
+	log.Debug().
+		Int("rules", len(p.filter.Rules())).
+		Int("source_count", len(dataSources)).
+		Msg("Applying filter rules")
+
+	filteredDataSources := p.filter.Apply(dataSources)
+
 	log.Debug().
-		Int("filtered_out", blacklistedCount).
+		Int("filtered_out", len(dataSources)-len(filteredDataSources)).
 		Int("remaining", len(filteredDataSources)).
-		Msg("Blacklist filtering complete")
+		Msg("Filter rules applied")
 
 	return filteredDataSources
 }
 
-func (p *App) GetSortedDataSources() ([]storage.DataSource, error) {
+// cachedDataSources returns the cached data sources, syncing first when the cache is empty or
+// older than the max age, and refreshing it in the background when it is older than the TTL
+func (p *App) cachedDataSources() ([]storage.DataSource, error) {
 	log.Debug().Msg("Retrieving data sources from database")
 	dataSources, err := p.db.RetrieveDatasources()
 	if err != nil {
@@ -69,8 +112,17 @@ func (p *App) GetSortedDataSources() ([]storage.DataSource, error) {
 
 	log.Debug().Int("count", len(dataSources)).Msg("Retrieved data sources from database")
 
-	if len(dataSources) == 0 {
-		log.Debug().Msg("No data sources found, syncing...")
+	syncedAt, err := p.db.LastSyncedAt()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the last sync time")
+	}
+
+	switch p.cacheAction(len(dataSources), syncedAt, time.Now()) {
+	case cacheRevalidate:
+		log.Debug().Time("syncedAt", syncedAt).Msg("Cache is stale, refreshing it in the background")
+		p.revalidate()
+	case cacheSync:
+		log.Debug().Time("syncedAt", syncedAt).Msg("Cache is empty or too old, syncing...")
 		if err := p.Sync(); err != nil {
 			log.Error().Err(err).Msg("Sync failed")
 			return nil, err
@@ -85,13 +137,32 @@ func (p *App) GetSortedDataSources() ([]storage.DataSource, error) {
 		log.Debug().Int("count", len(dataSources)).Msg("Retrieved data sources after sync")
 	}
 
-	log.Debug().Msg("Applying blacklist filters")
-	dataSources = p.applyBlacklist(dataSources)
+	for i := range dataSources {
+		p.applyConfigAlias(&dataSources[i])
+	}
+
+	return dataSources, nil
+}
+
+func (p *App) GetSortedDataSources() ([]storage.DataSource, error) {
+	return p.sortedDataSources(p.tagFilters)
+}
+
+// sortedDataSources returns the data sources passing the filter rules and the given tag filters
+func (p *App) sortedDataSources(tagFilters []TagFilter) ([]storage.DataSource, error) {
+	dataSources, err := p.cachedDataSources()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().Msg("Applying filter rules")
+	dataSources = p.applyFilter(dataSources)
+
+	log.Debug().Msg("Applying tag filters")
+	dataSources = applyTagFilters(dataSources, tagFilters)
 
-	log.Debug().Msg("Sorting data sources by last used time")
-	slices.SortFunc(dataSources, func(a, b storage.DataSource) int {
-		return int(b.LRU - a.LRU)
-	})
+	log.Debug().Str("mode", p.sortMode.String()).Msg("Sorting data sources")
+	sortDataSources(dataSources, p.sortMode, time.Now())
 
 	log.Debug().Int("final_count", len(dataSources)).Msg("Finished preparing data sources")
 	return dataSources, nil
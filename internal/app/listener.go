@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultReadyTimeout bounds the wait for the SDM listener to load its state
+const DefaultReadyTimeout = 30 * time.Second
+
+// ErrListenerNotRunning indicates that the SDM listener is stopped and may not be started
+var ErrListenerNotRunning = errors.New("sdm listener is not running, start it with `sdm listen --daemon` or set listener.autoStart")
+
+// ListenerConfig sets how the SDM listener is brought up before running commands
+type ListenerConfig struct {
+	AutoStart    bool          `mapstructure:"autoStart"`
+	ReadyTimeout time.Duration `mapstructure:"readyTimeout"`
+}
+
+// WithListener sets whether a stopped SDM listener is started and how long to wait for it
+func WithListener(cfg ListenerConfig) AppOption {
+	return func(p *App) {
+		p.listener = cfg
+	}
+}
+
+// ensureReady makes sure the SDM listener is running, has loaded its state and isn't
+// logged in with another account. It only checks once per application.
+func (p *App) ensureReady() error {
+	p.readyMu.Lock()
+	defer p.readyMu.Unlock()
+
+	// The daemon checks it before running the commands
+	if p.ready || p.daemon != nil {
+		return nil
+	}
+
+	if _, err := p.waitReady(); err != nil {
+		return err
+	}
+
+	// Another profile may be logged in, log it out so this account logs in on the first command
+	if err := p.ValidateAccount(); err != nil {
+		return err
+	}
+
+	p.ready = true
+	return nil
+}
+
+// waitReady starts the listener when allowed and polls SDM until its state is loaded.
+// It returns the last state reported by SDM.
+func (p *App) waitReady() (sdm.SdmReady, error) {
+	ctx, cancel := context.WithTimeout(p.context, p.timeout)
+	status, err := p.sdmWrapper.ReadyWithContext(ctx)
+	cancel()
+	if err != nil {
+		return status, fmt.Errorf("ready check failed: %w", err)
+	}
+
+	if status.Loaded() {
+		return status, nil
+	}
+
+	// The state of a logged out listener loads once the command fails as unauthorized and logs in
+	if status.LoggedOut() {
+		log.Debug().Msg("SDM listener is logged out, not waiting for its state")
+		return status, nil
+	}
+
+	if !status.ListenerRunning {
+		if !p.listener.AutoStart {
+			p.notify("❗SDM listener is not running", "Run `sdm listen --daemon`")
+			return status, ErrListenerNotRunning
+		}
+
+		log.Debug().Msg("Starting SDM listener")
+		p.notify("🚀 Starting SDM listener...", "")
+
+		if err := p.sdmWrapper.ListenWithContext(p.context); err != nil {
+			p.notify("❗SDM listener failed to start", err.Error())
+			return status, fmt.Errorf("failed to start listener: %w", err)
+		}
+	} else {
+		p.notify("⏳ Waiting for SDM to load its state...", "")
+	}
+
+	timeout := p.listener.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+
+	ctx, cancel = context.WithTimeout(p.context, timeout)
+	defer cancel()
+
+	status, err = p.sdmWrapper.WaitReadyWithContext(ctx, sdm.DefaultReadyInterval)
+	if err != nil {
+		p.notify("❗SDM is not ready", fmt.Sprintf("Gave up after %s", timeout))
+		return status, err
+	}
+
+	log.Debug().Msg("SDM is ready")
+	p.notify("✅ SDM is ready", "")
+	return status, nil
+}
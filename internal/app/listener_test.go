@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitReady(t *testing.T) {
+	tests := []struct {
+		name          string
+		ready         string
+		wantErr       error
+		notifications []string
+	}{
+		{"loaded", `{"account":"me@example.com","listener_running":true,"state_loaded":true}`, nil, nil},
+		{"logged out", `{"listener_running":true,"state_loaded":false}`, nil, nil},
+		{"loading", `{"account":"me@example.com","listener_running":true,"state_loaded":false}`, sdm.ErrNotReady,
+			[]string{"⏳ Waiting for SDM to load its state...", "❗SDM is not ready"}},
+		{"listener stopped", `{"listener_running":false,"state_loaded":false}`, ErrListenerNotRunning,
+			[]string{"❗SDM listener is not running"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			exe := fakeProgram(t, "sdm", "echo '"+tc.ready+"'")
+			var notifications []string
+			p := &App{
+				sdmWrapper: *sdm.NewSDMClient(exe),
+				context:    context.Background(),
+				timeout:    time.Second,
+				listener:   ListenerConfig{ReadyTimeout: 600 * time.Millisecond},
+				notifier: func(title, message string) {
+					notifications = append(notifications, title)
+				},
+			}
+
+			_, err := p.waitReady()
+			assert.Equal(t, tc.notifications, notifications)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import "github.com/martinlindhe/notify"
+
+// Notifier shows a notification to the user
+type Notifier func(title, message string)
+
+// DesktopNotifier shows the notifications on the desktop
+func DesktopNotifier(title, message string) {
+	notify.Notify("SDM CLI", title, message, "")
+}
+
+// WithNotifier sets how the user is notified, DesktopNotifier by default
+func WithNotifier(notifier Notifier) AppOption {
+	return func(p *App) {
+		p.notifier = notifier
+	}
+}
+
+// notify notifies the user, doing nothing when the application has no notifier
+func (p *App) notify(title, message string) {
+	if p.notifier != nil {
+		p.notifier(title, message)
+	}
+}
@@ -1,8 +1,7 @@
 package app
 
 import (
-	"encoding/json"
-
+	"github.com/marianozunino/sdm-ui/internal/sdm"
 	"github.com/marianozunino/sdm-ui/internal/storage"
 	"github.com/rs/zerolog/log"
 )
@@ -14,6 +13,7 @@ type ResourceType string
 const (
 	TypeRedis        ResourceType = "redis"
 	TypePostgres     ResourceType = "postgres"
+	TypeMySQL        ResourceType = "mysql"
 	TypeAmazonEKS    ResourceType = "amazoneks"
 	TypeAmazonES     ResourceType = "amazones"
 	TypeAthena       ResourceType = "athena"
@@ -22,44 +22,8 @@ const (
 	TypeRawTCP       ResourceType = "rawtcp"
 )
 
-// Resource represents the details of a resource
-type Resource struct {
-	Address          string `json:"address,omitempty"`
-	Connected        bool   `json:"connected"`
-	ConnectionStatus string `json:"connection_status"`
-	Hostname         string `json:"hostname"`
-	ID               string `json:"id"`
-	Message          string `json:"message"`
-	Name             string `json:"name"`
-	Tags             string `json:"tags"`
-	Type             string `json:"type"`
-	WebURL           string `json:"web_url,omitempty"`
-}
-
-// parseDataSources converts JSON-encoded resource data into a list of DataSource objects
-func parseDataSources(rawResources string) []storage.DataSource {
-	if rawResources == "" {
-		log.Warn().Msg("Empty resource data received")
-		return nil
-	}
-
-	log.Debug().
-		Int("raw_length", len(rawResources)).
-		Msg("Parsing resource data")
-
-	var resources []Resource
-	if err := json.Unmarshal([]byte(rawResources), &resources); err != nil {
-		log.Error().
-			Err(err).
-			Str("raw_data_sample", truncateString(rawResources, 100)).
-			Msg("Failed to parse resources JSON")
-		return nil
-	}
-
-	log.Debug().
-		Int("resource_count", len(resources)).
-		Msg("Successfully parsed resources")
-
+// parseDataSources converts the resources reported by sdm into a list of DataSource objects
+func parseDataSources(resources []sdm.Resource) []storage.DataSource {
 	// Pre-allocate dataSources slice
 	dataSources := make([]storage.DataSource, 0, len(resources))
 
@@ -91,11 +55,3 @@ func parseDataSources(rawResources string) []storage.DataSource {
 
 	return dataSources
 }
-
-// truncateString truncates a string to the specified length and adds "..." if truncated
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen] + "..."
-}
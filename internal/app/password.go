@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/marianozunino/sdm-ui/internal/libsecret"
 	"github.com/ncruces/zenity"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/term"
@@ -18,6 +22,7 @@ var (
 	ErrEmptyPassword      = errors.New("empty password provided")
 	ErrPasswordRetrieval  = errors.New("failed to retrieve password")
 	ErrUnknownPasswordCmd = errors.New("unknown password command")
+	ErrPasswordCanceled   = errors.New("password prompt canceled by user")
 )
 
 // PasswordCommand represents the method used to prompt the user for a password
@@ -25,10 +30,82 @@ type PasswordCommand string
 
 // Constants representing the different password command methods
 const (
-	PasswordCommandZenity PasswordCommand = "zenity" // Use Zenity GUI prompt for password
-	PasswordCommandCLI    PasswordCommand = "cli"    // Use CLI prompt for password
+	PasswordCommandZenity   PasswordCommand = "zenity"   // Use Zenity GUI prompt for password
+	PasswordCommandCLI      PasswordCommand = "cli"      // Use CLI prompt for password
+	PasswordCommandPinentry PasswordCommand = "pinentry" // Use pinentry through the Assuan protocol
+	PasswordCommandRofi     PasswordCommand = "rofi"     // Use rofi in password mode
+	PasswordCommandWofi     PasswordCommand = "wofi"     // Use wofi in password mode
+	PasswordCommandAskpass  PasswordCommand = "askpass"  // Use the $SDM_ASKPASS or $SSH_ASKPASS helper
+	PasswordCommandLauncher PasswordCommand = "launcher" // Use the password mode of the menu launcher
 )
 
+// PasswordCommands lists the supported password commands
+var PasswordCommands = []PasswordCommand{
+	PasswordCommandZenity,
+	PasswordCommandCLI,
+	PasswordCommandPinentry,
+	PasswordCommandRofi,
+	PasswordCommandWofi,
+	PasswordCommandAskpass,
+	PasswordCommandLauncher,
+}
+
+// String returns the string representation of the PasswordCommand
+func (pc PasswordCommand) String() string {
+	return string(pc)
+}
+
+// ParsePasswordCommand validates a password command name
+func ParsePasswordCommand(name string) (PasswordCommand, error) {
+	for _, pc := range PasswordCommands {
+		if string(pc) == name {
+			return pc, nil
+		}
+	}
+
+	names := make([]string, len(PasswordCommands))
+	for i, pc := range PasswordCommands {
+		names[i] = pc.String()
+	}
+	return "", fmt.Errorf("%w: %s (available: %s)", ErrUnknownPasswordCmd, name, strings.Join(names, ", "))
+}
+
+// resolvePasswordCommand validates the password command and replaces launcher with the
+// password mode of the menu launcher, or the terminal prompt when there is no menu window
+func (p *App) resolvePasswordCommand() error {
+	pc, err := ParsePasswordCommand(p.passwordCommand.String())
+	if err != nil {
+		return err
+	}
+
+	if pc == PasswordCommandLauncher {
+		switch p.dmenuCommand {
+		case DMenuCommandRofi:
+			pc = PasswordCommandRofi
+		case DMenuCommandWofi:
+			pc = PasswordCommandWofi
+		case DMenuCommandFzf, DMenuCommandNoop:
+			pc = PasswordCommandCLI
+		default:
+			return fmt.Errorf("%w: %s has no password mode, use rofi or wofi", ErrUnknownPasswordCmd, p.dmenuCommand)
+		}
+		log.Debug().Str("method", pc.String()).Msg("Using the launcher password mode")
+	}
+
+	p.passwordCommand = pc
+	return nil
+}
+
+// askpassHelper returns the external password helper, $SDM_ASKPASS taking precedence over $SSH_ASKPASS
+func askpassHelper() (string, error) {
+	for _, env := range []string{"SDM_ASKPASS", "SSH_ASKPASS"} {
+		if helper := os.Getenv(env); helper != "" {
+			return helper, nil
+		}
+	}
+	return "", errors.New("askpass requires $SDM_ASKPASS or $SSH_ASKPASS")
+}
+
 // retrievePassword attempts to retrieve the password from the keyring.
 // If the password is not found or an error occurs, it prompts the user to enter the password.
 func (p *App) retrievePassword() (string, error) {
@@ -70,7 +147,7 @@ func (p *App) retrievePassword() (string, error) {
 			Err(err).
 			Str("method", string(p.passwordCommand)).
 			Msg("Failed to retrieve password from user")
-		return "", fmt.Errorf("%w: %v", ErrPasswordRetrieval, err)
+		return "", fmt.Errorf("%w: %w", ErrPasswordRetrieval, err)
 	}
 
 	// Check for empty password
@@ -81,7 +158,9 @@ func (p *App) retrievePassword() (string, error) {
 
 	// Store the password in the keyring
 	log.Debug().Str("account", p.account).Msg("Saving password to keyring")
-	if err := p.keyring.SetSecret(p.account, password); err != nil {
+	if err := p.keyring.SetSecret(p.account, password); errors.Is(err, libsecret.ErrReadOnly) {
+		log.Debug().Err(err).Msg("Password not saved")
+	} else if err != nil {
 		log.Warn().
 			Err(err).
 			Str("account", p.account).
@@ -94,19 +173,29 @@ func (p *App) retrievePassword() (string, error) {
 	return password, nil
 }
 
+// forgetPassword removes the stored password of the account after a failed login
+func (p *App) forgetPassword() {
+	if err := p.keyring.DeleteSecret(p.account); err != nil {
+		log.Debug().
+			Err(err).
+			Str("account", p.account).
+			Msg("Failed to delete password from keyring")
+	}
+}
+
 // askForPassword prompts the user for a password based on the specified PasswordCommand method
 func (p *App) askForPassword(pc PasswordCommand) (string, error) {
 	switch pc {
 	case PasswordCommandZenity:
 		log.Debug().Msg("Using Zenity to prompt for password")
-		title := fmt.Sprintf("Enter password for %s", p.account)
+		title := p.passwordPrompt()
 		_, pwd, err := zenity.Password(
 			zenity.Title(title),
 		)
 		if err != nil {
-			if strings.Contains(err.Error(), "canceled") {
+			if errors.Is(err, zenity.ErrCanceled) {
 				log.Debug().Msg("User canceled Zenity password prompt")
-				return "", fmt.Errorf("password prompt canceled by user")
+				return "", ErrPasswordCanceled
 			}
 			log.Error().Err(err).Msg("Failed to retrieve password using Zenity")
 			return "", err
@@ -128,8 +217,57 @@ func (p *App) askForPassword(pc PasswordCommand) (string, error) {
 
 		return string(bytePassword), nil
 
+	case PasswordCommandPinentry:
+		log.Debug().Msg("Using pinentry to prompt for password")
+		return pinentryPassword(p.context, "pinentry", p.passwordPrompt())
+
+	case PasswordCommandRofi:
+		log.Debug().Msg("Using rofi to prompt for password")
+		return runPasswordPrompt(p.context, "rofi", []int{1},
+			"-dmenu", "-password", "-p", "Password", "-mesg", p.passwordPrompt(),
+			"-theme-str", "listview { enabled: false; }")
+
+	case PasswordCommandWofi:
+		log.Debug().Msg("Using wofi to prompt for password")
+		return runPasswordPrompt(p.context, "wofi", []int{1},
+			"--dmenu", "--password", "--prompt", p.passwordPrompt(), "--lines", "1")
+
+	case PasswordCommandAskpass:
+		helper, err := askpassHelper()
+		if err != nil {
+			return "", err
+		}
+		log.Debug().Str("helper", helper).Msg("Using askpass helper to prompt for password")
+		// Helpers exit with a non-zero status when the dialog is dismissed
+		return runPasswordPrompt(p.context, helper, nil, p.passwordPrompt())
+
 	default:
 		log.Error().Str("command", string(pc)).Msg("Unknown password command")
 		return "", fmt.Errorf("%w: %s", ErrUnknownPasswordCmd, pc)
 	}
 }
+
+// passwordPrompt returns the message shown when asking for the password
+func (p *App) passwordPrompt() string {
+	return fmt.Sprintf("Enter password for %s", p.account)
+}
+
+// runPasswordPrompt runs a program printing the password on stdout. The exit codes in
+// cancelCodes, or any non-zero exit code when it is nil, mean the prompt was dismissed.
+func runPasswordPrompt(ctx context.Context, name string, cancelCodes []int, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader("")
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (cancelCodes == nil || slices.Contains(cancelCodes, exitErr.ExitCode())) {
+			log.Debug().Str("command", name).Int("code", exitErr.ExitCode()).Msg("User canceled password prompt")
+			return "", ErrPasswordCanceled
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	// Only strip the line ending, spaces may be part of the password
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProgram writes a shell script with the given body and returns its path
+func fakeProgram(t *testing.T, name, body string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755))
+	return path
+}
+
+func TestResolvePasswordCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  PasswordCommand
+		launcher DMenuCommand
+		want     PasswordCommand
+		wantErr  string
+	}{
+		{name: "explicit", command: PasswordCommandPinentry, launcher: DMenuCommandRofi, want: PasswordCommandPinentry},
+		{name: "launcher rofi", command: PasswordCommandLauncher, launcher: DMenuCommandRofi, want: PasswordCommandRofi},
+		{name: "launcher wofi", command: PasswordCommandLauncher, launcher: DMenuCommandWofi, want: PasswordCommandWofi},
+		{name: "launcher fzf", command: PasswordCommandLauncher, launcher: DMenuCommandFzf, want: PasswordCommandCLI},
+		{name: "launcher without menu", command: PasswordCommandLauncher, launcher: DMenuCommandNoop, want: PasswordCommandCLI},
+		{name: "launcher without password mode", command: PasswordCommandLauncher, launcher: DMenuCommandFuzzel, wantErr: "fuzzel has no password mode"},
+		{name: "unknown", command: "kdialog", launcher: DMenuCommandRofi, wantErr: "unknown password command: kdialog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &App{passwordCommand: tt.command, dmenuCommand: tt.launcher}
+
+			err := p.resolvePasswordCommand()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.passwordCommand)
+		})
+	}
+}
+
+func TestAssuanEscaping(t *testing.T) {
+	assert.Equal(t, "100%25 sure%0Anext", assuanEncode("100% sure\nnext"))
+	assert.Equal(t, "100% sure\nnext", assuanDecode("100%25 sure%0Anext"))
+	assert.Equal(t, "plain", assuanDecode("plain"))
+	assert.Equal(t, "trailing%", assuanDecode("trailing%"))
+}
+
+func TestPinentryPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		getpin  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "password",
+			getpin: `echo "S PASSWORD_FROM_CACHE"; echo "D p%25ss w0rd"; echo OK`,
+			want:   "p%ss w0rd",
+		},
+		{
+			name:    "canceled",
+			getpin:  `echo "ERR 83886179 Operation cancelled <Pinentry>"`,
+			wantErr: ErrPasswordCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := fakeProgram(t, "pinentry", `echo "OK Pleased to meet you"
+while read -r command args; do
+	case "$command" in
+	GETPIN) `+tt.getpin+` ;;
+	BYE) echo OK; exit 0 ;;
+	*) echo OK ;;
+	esac
+done`)
+
+			password, err := pinentryPassword(context.Background(), exe, "Enter password for a@b.c")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, password)
+		})
+	}
+}
+
+func TestPinentryPassword_Error(t *testing.T) {
+	exe := fakeProgram(t, "pinentry", `echo "ERR 83886360 No such file <Pinentry>"`)
+
+	_, err := pinentryPassword(context.Background(), exe, "Enter password")
+	assert.ErrorContains(t, err, "pinentry handshake failed")
+	assert.NotErrorIs(t, err, ErrPasswordCanceled)
+}
+
+func TestRunPasswordPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		cancelCodes []int
+		want        string
+		wantErr     error
+		wantErrMsg  string
+	}{
+		{name: "password", body: `printf ' spaced pw \n'`, cancelCodes: []int{1}, want: " spaced pw "},
+		{name: "cancel code", body: "exit 1", cancelCodes: []int{1}, wantErr: ErrPasswordCanceled},
+		{name: "other exit code", body: "exit 2", cancelCodes: []int{1}, wantErrMsg: "exit status 2"},
+		{name: "any exit code cancels", body: "exit 2", wantErr: ErrPasswordCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := fakeProgram(t, "prompt", tt.body)
+
+			password, err := runPasswordPrompt(context.Background(), exe, tt.cancelCodes, "Enter password")
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantErrMsg != "":
+				assert.ErrorContains(t, err, tt.wantErrMsg)
+				assert.NotErrorIs(t, err, ErrPasswordCanceled)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, password)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// Pin pins the data source with the given name or alias to the top of every list
+func (p *App) Pin(name string) error {
+	return p.setPinned(name, true)
+}
+
+// Unpin removes the pin from the data source with the given name or alias
+func (p *App) Unpin(name string) error {
+	return p.setPinned(name, false)
+}
+
+// Pins writes the pinned data sources to the provided writer
+func (p *App) Pins(w io.Writer, withHeader bool) error {
+	dataSources, err := p.cachedDataSources()
+	if err != nil {
+		return err
+	}
+
+	pinned := make([]storage.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		if ds.Pinned {
+			pinned = append(pinned, ds)
+		}
+	}
+
+	sortDataSources(pinned, p.sortMode, time.Now())
+	p.PrintDataSources(pinned, w, withHeader)
+	return nil
+}
+
+// togglePin flips the pin of a data source and notifies the user
+func (p *App) togglePin(ds storage.DataSource) error {
+	if err := p.setPinned(ds.Name, !ds.Pinned); err != nil {
+		return err
+	}
+
+	title := "📌 Data Source Pinned"
+	if ds.Pinned {
+		title = "📌 Data Source Unpinned"
+	}
+	p.notify(title, ds.Name)
+
+	return nil
+}
+
+// setPinned updates the pin of a data source, syncing once if it isn't cached yet
+func (p *App) setPinned(nameOrAlias string, pinned bool) error {
+	name := p.resolveName(nameOrAlias)
+
+	err := p.db.SetPinned(name, pinned)
+	if errors.Is(err, storage.ErrDataSourceNotFound) {
+		log.Debug().Str("datasource", name).Msg("Data source not cached, syncing before pinning")
+		if err := p.Sync(); err != nil {
+			return err
+		}
+		name = p.resolveName(nameOrAlias)
+		err = p.db.SetPinned(name, pinned)
+	}
+
+	if errors.Is(err, storage.ErrDataSourceNotFound) {
+		return fmt.Errorf("%w: %s", ErrResourceNotFound, nameOrAlias)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pin for %s: %w", name, err)
+	}
+
+	log.Debug().
+		Str("datasource", name).
+		Bool("pinned", pinned).
+		Msg("Updated data source pin")
+	return nil
+}
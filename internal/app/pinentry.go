@@ -0,0 +1,147 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// gpgErrCanceled is the libgpg-error code pinentry reports when the dialog is dismissed
+const gpgErrCanceled = 99
+
+// pinentryPassword asks for a password with pinentry, speaking the Assuan protocol on its stdin/stdout
+func pinentryPassword(ctx context.Context, exe, description string) (string, error) {
+	cmd := exec.CommandContext(ctx, exe)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start pinentry: %w", err)
+	}
+	defer func() {
+		stdin.Close()
+		if err := cmd.Wait(); err != nil {
+			log.Debug().Err(err).Msg("pinentry exited with an error")
+		}
+	}()
+
+	r := bufio.NewReader(stdout)
+
+	// pinentry greets with OK once it is ready
+	if _, err := assuanResponse(r); err != nil {
+		return "", fmt.Errorf("pinentry handshake failed: %w", err)
+	}
+
+	commands := [][2]string{
+		{"SETTITLE", "SDM UI"},
+		{"SETDESC", description},
+		{"SETPROMPT", "Password:"},
+	}
+	// Terminal pinentries can't find the tty on their own since stdin is a pipe
+	if tty := os.Getenv("GPG_TTY"); tty != "" {
+		commands = append(commands, [2]string{"OPTION", "ttyname=" + tty})
+	}
+
+	for _, command := range commands {
+		if _, err := assuanCommand(stdin, r, command[0]+" "+assuanEncode(command[1])); err != nil {
+			return "", fmt.Errorf("pinentry %s failed: %w", command[0], err)
+		}
+	}
+
+	password, err := assuanCommand(stdin, r, "GETPIN")
+	if err != nil {
+		return "", err
+	}
+
+	// Best effort, pinentry exits once stdin is closed anyway
+	fmt.Fprintln(stdin, "BYE")
+
+	return password, nil
+}
+
+// assuanCommand sends a command and waits for its response
+func assuanCommand(w io.Writer, r *bufio.Reader, command string) (string, error) {
+	if _, err := fmt.Fprintln(w, command); err != nil {
+		return "", err
+	}
+	return assuanResponse(r)
+}
+
+// assuanResponse reads lines until OK or ERR and returns the data lines sent before it.
+// A canceled pinentry dialog is reported as ErrPasswordCanceled.
+func assuanResponse(r *bufio.Reader) (string, error) {
+	var data strings.Builder
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("pinentry closed the connection")
+			}
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return data.String(), nil
+		case strings.HasPrefix(line, "D "):
+			data.WriteString(assuanDecode(line[2:]))
+		case strings.HasPrefix(line, "ERR "):
+			return "", assuanError(line[4:])
+		default:
+			// Status lines (S) and comments (#) carry nothing we need
+		}
+	}
+}
+
+// assuanError converts an ERR line into an error
+func assuanError(message string) error {
+	codeStr, _, _ := strings.Cut(message, " ")
+	code, err := strconv.ParseUint(codeStr, 10, 32)
+	if err == nil && code&0xffff == gpgErrCanceled {
+		return ErrPasswordCanceled
+	}
+	return fmt.Errorf("pinentry error: %s", message)
+}
+
+// assuanEncode percent-escapes the characters Assuan can't carry in a command argument
+func assuanEncode(s string) string {
+	replacer := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	return replacer.Replace(s)
+}
+
+// assuanDecode reverses the percent-escaping of data lines
+func assuanDecode(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
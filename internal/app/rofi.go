@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~marianozunino/go-rofi/entry"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// Rofi exit codes for kb-custom-1 through kb-custom-19
+const (
+	rofiCustomKeyFirstExitCode = 10
+	rofiCustomKeyLastExitCode  = 28
+)
+
+// rofiKeybinding binds a key combination to rofi's kb-custom-N slot
+type rofiKeybinding struct {
+	Slot int    // N in kb-custom-N, from 1 to 19
+	Key  string // Key combination, e.g. "Alt+p"
+	Hint string // Short description shown in the message bar
+}
+
+// rofiSelect runs rofi in dmenu mode with custom keybindings and row states.
+// It returns the index of the selected entry and the slot of the custom key used, or 0 for a regular selection.
+func rofiSelect(ctx context.Context, prompt string, entries []*entry.Entry, opts menuOptions) (int, int, error) {
+	rofi, err := exec.LookPath(DMenuCommandRofi.String())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.Build())
+	}
+
+	// Report the index of the selected entry instead of its text, and only accept listed entries
+	args := []string{"-dmenu", "-p", prompt, "-format", "i", "-no-custom"}
+
+	if opts.Markup {
+		args = append(args, "-markup-rows")
+	}
+	if len(opts.Active) > 0 {
+		args = append(args, "-a", joinIndices(opts.Active))
+	}
+	if len(opts.Urgent) > 0 {
+		args = append(args, "-u", joinIndices(opts.Urgent))
+	}
+
+	hints := make([]string, 0, len(opts.Keybindings))
+	for _, kb := range opts.Keybindings {
+		args = append(args, fmt.Sprintf("-kb-custom-%d", kb.Slot), kb.Key)
+		hints = append(hints, fmt.Sprintf("<b>%s</b> %s", kb.Key, kb.Hint))
+	}
+	if len(hints) > 0 {
+		args = append(args, "-mesg", strings.Join(hints, "  "))
+	}
+
+	cmd := exec.CommandContext(ctx, rofi, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+
+	output, err := cmd.Output()
+	if err == nil {
+		idx, err := parseRofiIndex(output, len(entries))
+		return idx, 0, err
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, 0, err
+	}
+
+	code := exitErr.ExitCode()
+	switch {
+	case code == 1:
+		log.Debug().Msg("User canceled rofi selection")
+		return 0, 0, ErrNoSelection
+	case code >= rofiCustomKeyFirstExitCode && code <= rofiCustomKeyLastExitCode:
+		slot := code - rofiCustomKeyFirstExitCode + 1
+		if !slices.ContainsFunc(opts.Keybindings, func(kb rofiKeybinding) bool { return kb.Slot == slot }) {
+			return 0, 0, fmt.Errorf("unexpected rofi custom key kb-custom-%d", slot)
+		}
+		idx, err := parseRofiIndex(output, len(entries))
+		if err != nil {
+			return 0, 0, err
+		}
+		log.Debug().Int("slot", slot).Int("index", idx).Msg("Custom key used in rofi")
+		return idx, slot, nil
+	default:
+		return 0, 0, err
+	}
+}
+
+// parseRofiIndex parses the entry index printed by rofi with -format i
+func parseRofiIndex(output []byte, count int) (int, error) {
+	idx, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected rofi output %q: %w", output, err)
+	}
+	if idx < 0 || idx >= count {
+		// rofi prints -1 when nothing matches the filter
+		log.Debug().Int("index", idx).Msg("Rofi selection does not match any entry")
+		return 0, ErrNoSelection
+	}
+	return idx, nil
+}
+
+// defaultTypeIcons are the icons of each resource type, from the freedesktop icon naming specification
+var defaultTypeIcons = map[ResourceType]string{
+	TypePostgres:     "drive-harddisk",
+	TypeMySQL:        "drive-harddisk",
+	TypeRedis:        "drive-multidisk",
+	TypeAmazonEKS:    "utilities-terminal",
+	TypeAmazonES:     "system-search",
+	TypeAthena:       "x-office-spreadsheet",
+	TypeHTTPNoAuth:   "applications-internet",
+	TypeAmazonMQAMQP: "mail-send-receive",
+	TypeRawTCP:       "network-wired",
+}
+
+// defaultIcon is used for resource types without an icon
+const defaultIcon = "network-server"
+
+// typeIcon returns the icon of a resource type, preferring the icons from the config file
+func (p *App) typeIcon(resourceType string) string {
+	key := strings.ToLower(resourceType)
+	if icon, ok := p.icons[key]; ok {
+		return icon
+	}
+	for t, icon := range defaultTypeIcons {
+		if strings.ToLower(string(t)) == key {
+			return icon
+		}
+	}
+	return defaultIcon
+}
+
+// rofiRows renders the data sources as rofi rows with an icon per type, Pango markup and the
+// name, type and tags as invisible search terms. Connected rows are active and rows with an
+// unexpected status are urgent.
+func (p *App) rofiRows(dataSources []storage.DataSource) ([]*entry.Entry, menuOptions) {
+	entries := make([]*entry.Entry, 0, len(dataSources))
+	opts := menuOptions{Markup: true}
+
+	for i, ds := range dataSources {
+		var text strings.Builder
+		if ds.Pinned {
+			text.WriteString("📌 ")
+		}
+		text.WriteString("<b>" + html.EscapeString(displayName(ds)) + "</b>")
+		if ds.Alias != "" {
+			text.WriteString(" <i>" + html.EscapeString(ds.Name) + "</i>")
+		}
+		if ds.Address != "" {
+			text.WriteString("  <span alpha=\"60%\">" + html.EscapeString(ds.Address) + "</span>")
+		}
+
+		meta := []string{ds.Name, ds.Type}
+		keys := make([]string, 0, len(ds.Tags))
+		for key := range ds.Tags {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if value := ds.Tags[key]; value != "" {
+				meta = append(meta, key+"="+value)
+				continue
+			}
+			meta = append(meta, key)
+		}
+
+		entries = append(entries, entry.New(text.String(),
+			entry.WithIcon(p.typeIcon(ds.Type)),
+			entry.WithMeta(strings.Join(meta, " ")),
+		))
+
+		switch ds.Status {
+		case "connected":
+			opts.Active = append(opts.Active, i)
+		case "not connected", "":
+		default:
+			opts.Urgent = append(opts.Urgent, i)
+		}
+	}
+
+	return entries, opts
+}
+
+// joinIndices formats row indices the way rofi's -a and -u options expect them
+func joinIndices(indices []int) string {
+	parts := make([]string, 0, len(indices))
+	for _, i := range indices {
+		parts = append(parts, strconv.Itoa(i))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRofiIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected int
+		err      error
+	}{
+		{"FirstEntry", "0\n", 0, nil},
+		{"LastEntry", "2\n", 2, nil},
+		{"NoMatch", "-1\n", 0, ErrNoSelection},
+		{"OutOfRange", "3\n", 0, ErrNoSelection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, err := parseRofiIndex([]byte(tt.output), 3)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, idx)
+		})
+	}
+
+	_, err := parseRofiIndex([]byte("payments-db-prod"), 3)
+	assert.Error(t, err)
+}
+
+func TestRofiRows(t *testing.T) {
+	p := &App{icons: map[string]string{"httpnoauth": "firefox"}}
+
+	dataSources := []storage.DataSource{
+		{Name: "payments-db-prod", Alias: "pay", Type: "postgres", Status: "connected", Address: "localhost:10001", Pinned: true, Tags: map[string]string{"team": "payments", "env": "prod"}},
+		{Name: "grafana", Type: "httpNoAuth", Status: "not connected", Address: "http://grafana.example.com/?a=1&b=2"},
+		{Name: "queue", Type: "unknown", Status: "unreachable"},
+	}
+
+	entries, opts := p.rofiRows(dataSources)
+
+	assert.Equal(t, "📌 <b>pay</b> <i>payments-db-prod</i>  <span alpha=\"60%\">localhost:10001</span>\x00icon\x1fdrive-harddisk\x1fmeta\x1fpayments-db-prod postgres env=prod team=payments", entries[0].Build())
+	assert.Equal(t, "<b>grafana</b>  <span alpha=\"60%\">http://grafana.example.com/?a=1&amp;b=2</span>\x00icon\x1ffirefox\x1fmeta\x1fgrafana httpNoAuth", entries[1].Build())
+	assert.Equal(t, "<b>queue</b>\x00icon\x1f"+defaultIcon+"\x1fmeta\x1fqueue unknown", entries[2].Build())
+
+	assert.True(t, opts.Markup)
+	assert.Equal(t, []int{0}, opts.Active)
+	assert.Equal(t, []int{2}, opts.Urgent)
+}
+
+func TestAccountRows(t *testing.T) {
+	p := &App{dmenuCommand: DMenuCommandFuzzel}
+
+	accounts := []Account{
+		{Email: "me@example.com"},
+		{Profile: "staging", Email: "me+staging@example.com"},
+		{Profile: "prod", Email: "me+prod@example.com"},
+	}
+
+	entries, opts := p.accountRows(accounts, "me+staging@example.com")
+
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "default\tme@example.com", entryText(entries[0]))
+	assert.Equal(t, "✔ staging\tme+staging@example.com", entryText(entries[1]))
+	assert.Equal(t, "prod\t\tme+prod@example.com", entryText(entries[2]))
+	assert.Equal(t, []int{1}, opts.Active)
+}
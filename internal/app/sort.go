@@ -0,0 +1,145 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+)
+
+// ErrUnknownSortMode is returned when an unsupported sort mode is requested
+var ErrUnknownSortMode = errors.New("unknown sort mode")
+
+// SortMode represents how data sources are ordered in lists and menus
+type SortMode string
+
+// Available sort modes
+const (
+	SortFrecency SortMode = "frecency" // Most frequently and recently used first
+	SortLRU      SortMode = "lru"      // Most recently used first
+	SortName     SortMode = "name"     // Alphabetically by name
+	SortType     SortMode = "type"     // Grouped by resource type
+	SortStatus   SortMode = "status"   // Connected resources first
+)
+
+// SortModes lists every supported sort mode
+var SortModes = []SortMode{SortFrecency, SortLRU, SortName, SortType, SortStatus}
+
+// String returns the string representation of the sort mode
+func (s SortMode) String() string {
+	return string(s)
+}
+
+// ParseSortMode validates a sort mode name
+func ParseSortMode(mode string) (SortMode, error) {
+	sortMode := SortMode(strings.ToLower(strings.TrimSpace(mode)))
+	if slices.Contains(SortModes, sortMode) {
+		return sortMode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownSortMode, mode)
+}
+
+// frecencyBuckets weights a use by its age, in the style of Firefox's frecency
+var frecencyBuckets = []struct {
+	maxAge time.Duration
+	weight float64
+}{
+	{4 * 24 * time.Hour, 100},
+	{14 * 24 * time.Hour, 70},
+	{31 * 24 * time.Hour, 50},
+	{90 * 24 * time.Hour, 30},
+}
+
+// frecencyBaseWeight is the weight of uses older than every bucket
+const frecencyBaseWeight = 10
+
+// frecency scores a data source by how often and how recently it was used.
+// The average weight of the recent uses is scaled by the total use count.
+func frecency(ds storage.DataSource, now time.Time) float64 {
+	if ds.UseCount == 0 || len(ds.RecentUses) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, use := range ds.RecentUses {
+		age := now.Sub(time.Unix(use, 0))
+		weight := float64(frecencyBaseWeight)
+		for _, bucket := range frecencyBuckets {
+			if age <= bucket.maxAge {
+				weight = bucket.weight
+				break
+			}
+		}
+		total += weight
+	}
+
+	return float64(ds.UseCount) * total / float64(len(ds.RecentUses))
+}
+
+// sortDataSources orders the data sources in place according to the sort mode.
+// Pinned data sources always come first.
+func sortDataSources(dataSources []storage.DataSource, mode SortMode, now time.Time) {
+	byName := func(a, b storage.DataSource) int {
+		return strings.Compare(a.Name, b.Name)
+	}
+	byLRU := func(a, b storage.DataSource) int {
+		return cmp.Compare(b.LRU, a.LRU)
+	}
+	byFrecency := func(a, b storage.DataSource) int {
+		return cmp.Compare(frecency(b, now), frecency(a, now))
+	}
+
+	var comparators []func(a, b storage.DataSource) int
+	switch mode {
+	case SortLRU:
+		comparators = []func(a, b storage.DataSource) int{byLRU, byName}
+	case SortName:
+		comparators = []func(a, b storage.DataSource) int{byName}
+	case SortType:
+		byType := func(a, b storage.DataSource) int {
+			return strings.Compare(a.Type, b.Type)
+		}
+		comparators = []func(a, b storage.DataSource) int{byType, byFrecency, byName}
+	case SortStatus:
+		byStatus := func(a, b storage.DataSource) int {
+			return cmp.Compare(statusRank(a.Status), statusRank(b.Status))
+		}
+		comparators = []func(a, b storage.DataSource) int{byStatus, byFrecency, byName}
+	default:
+		comparators = []func(a, b storage.DataSource) int{byFrecency, byLRU, byName}
+	}
+
+	byPinned := func(a, b storage.DataSource) int {
+		return cmp.Compare(pinRank(a), pinRank(b))
+	}
+	comparators = append([]func(a, b storage.DataSource) int{byPinned}, comparators...)
+
+	slices.SortStableFunc(dataSources, func(a, b storage.DataSource) int {
+		for _, compare := range comparators {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+// statusRank puts connected data sources before the rest
+func statusRank(status string) int {
+	if status == "connected" {
+		return 0
+	}
+	return 1
+}
+
+// pinRank puts pinned data sources before the rest
+func pinRank(ds storage.DataSource) int {
+	if ds.Pinned {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortDataSources(t *testing.T) {
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour).Unix()
+	monthsAgo := now.Add(-120 * 24 * time.Hour).Unix()
+
+	dataSources := []storage.DataSource{
+		{Name: "once-an-hour-ago", Type: "redis", Status: "not connected", LRU: hourAgo, UseCount: 1, RecentUses: []int64{hourAgo}},
+		{Name: "daily-db", Type: "postgres", Status: "connected", LRU: hourAgo - 60, UseCount: 50, RecentUses: []int64{hourAgo - 120, hourAgo - 60}},
+		{Name: "abandoned", Type: "postgres", Status: "not connected", LRU: monthsAgo, UseCount: 3, RecentUses: []int64{monthsAgo}},
+		{Name: "never-used", Type: "athena", Status: "not connected"},
+	}
+
+	tests := []struct {
+		mode     SortMode
+		expected []string
+	}{
+		{SortFrecency, []string{"daily-db", "once-an-hour-ago", "abandoned", "never-used"}},
+		{SortLRU, []string{"once-an-hour-ago", "daily-db", "abandoned", "never-used"}},
+		{SortName, []string{"abandoned", "daily-db", "never-used", "once-an-hour-ago"}},
+		{SortType, []string{"never-used", "daily-db", "abandoned", "once-an-hour-ago"}},
+		{SortStatus, []string{"daily-db", "once-an-hour-ago", "abandoned", "never-used"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.mode.String(), func(t *testing.T) {
+			sorted := append([]storage.DataSource(nil), dataSources...)
+			sortDataSources(sorted, tc.mode, now)
+
+			names := make([]string, 0, len(sorted))
+			for _, ds := range sorted {
+				names = append(names, ds.Name)
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestSortDataSources_PinnedFirst(t *testing.T) {
+	dataSources := []storage.DataSource{
+		{Name: "alpha", LRU: 300},
+		{Name: "beta", Pinned: true},
+		{Name: "gamma", LRU: 200, Pinned: true},
+	}
+
+	for _, mode := range SortModes {
+		t.Run(mode.String(), func(t *testing.T) {
+			sorted := append([]storage.DataSource(nil), dataSources...)
+			sortDataSources(sorted, mode, time.Now())
+
+			assert.True(t, sorted[0].Pinned)
+			assert.True(t, sorted[1].Pinned)
+			assert.Equal(t, "alpha", sorted[2].Name)
+		})
+	}
+}
+
+func TestParseSortMode(t *testing.T) {
+	mode, err := ParseSortMode(" LRU ")
+	require.NoError(t, err)
+	assert.Equal(t, SortLRU, mode)
+
+	_, err = ParseSortMode("random")
+	assert.ErrorIs(t, err, ErrUnknownSortMode)
+}
@@ -1,26 +1,76 @@
 package app
 
 import (
-	"bytes"
+	"fmt"
+	"io"
 
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/marianozunino/sdm-ui/internal/storage"
 	"github.com/rs/zerolog/log"
 )
 
 func (p *App) Sync() error {
+	_, err := p.syncDataSources()
+	return err
+}
+
+// SyncReport syncs and writes how many data sources were added, removed and changed,
+// followed by their names when diff is set
+func (p *App) SyncReport(w io.Writer, diff bool) error {
+	result, err := p.syncDataSources()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Synced %d resources: %d added, %d removed, %d changed\n",
+		result.Total, len(result.Added), len(result.Removed), len(result.Changed))
+
+	if diff {
+		for _, line := range syncDiff(result) {
+			fmt.Fprintln(w, line)
+		}
+	}
+	return nil
+}
+
+// syncDataSources stores the current resources, removing the ones that vanished
+func (p *App) syncDataSources() (storage.SyncResult, error) {
 	log.Debug().Msg("Syncing...")
 
-	statusesBuffer := new(bytes.Buffer)
+	var resources []sdm.Resource
 
 	if err := p.RetryCommand(func() error {
-		statusesBuffer.Reset()
-		return p.sdmWrapper.Status(statusesBuffer)
+		var err error
+		resources, err = p.status()
+		return err
 	}); err != nil {
 		log.Debug().Msg("Failed to sync with SDM")
-		return err
+		return storage.SyncResult{}, err
 	}
 
-	dataSources := parseDataSources(statusesBuffer.String())
-	p.db.StoreServers(dataSources)
+	dataSources := parseDataSources(resources)
+	return p.db.StoreServers(dataSources, completeStatus(resources))
+}
 
-	return nil
+// completeStatus reports whether the resources are everything the account has access to, so
+// that the cached ones missing from them can be removed. A status output without a list, e.g.
+// empty or `null`, is parsed as nil: it is more likely a glitch of the listener than every
+// resource being revoked, which sdm reports as `[]`.
+func completeStatus(resources []sdm.Resource) bool {
+	return resources != nil
+}
+
+// syncDiff lists the changes of a sync, prefixed with + when added, - when removed and ~ when changed
+func syncDiff(result storage.SyncResult) []string {
+	lines := make([]string, 0, len(result.Added)+len(result.Removed)+len(result.Changed))
+	for _, name := range result.Added {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range result.Removed {
+		lines = append(lines, "- "+name)
+	}
+	for _, name := range result.Changed {
+		lines = append(lines, "~ "+name)
+	}
+	return lines
 }
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync_StatusWithoutList(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected int
+	}{
+		{"", 1},
+		{"null", 1},
+		{"[]", 0}, // Every resource was revoked
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.output, func(t *testing.T) {
+			p := newFakeSDMApp(t, "payments-db")
+			require.NoError(t, p.db.SetPinned("payments-db", true))
+
+			p.sdmWrapper = *sdm.NewSDMClient(fakeProgram(t, "sdm", `case "$1" in
+ready) echo '{"account":"me@example.com","listener_running":true,"state_loaded":true}' ;;
+status) echo '`+tc.output+`' ;;
+esac`))
+
+			require.NoError(t, p.Sync())
+
+			dataSources, err := p.db.RetrieveDatasources()
+			require.NoError(t, err)
+			assert.Len(t, dataSources, tc.expected)
+			if tc.expected > 0 {
+				assert.Equal(t, []storage.DataSource{{Name: "payments-db", Address: "localhost:10001", Pinned: true}}, dataSources,
+					"a status without a list should keep the cache")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidTagFilter indicates that a tag filter expression could not be parsed
+var ErrInvalidTagFilter = errors.New("invalid tag filter")
+
+// TagFilter matches data sources on one of their tags
+type TagFilter struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// ParseTagFilter parses a "key=value" or "key!=value" expression
+func ParseTagFilter(expr string) (TagFilter, error) {
+	negate := false
+	key, value, found := strings.Cut(expr, "!=")
+	if found {
+		negate = true
+	} else {
+		key, value, found = strings.Cut(expr, "=")
+	}
+
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return TagFilter{}, fmt.Errorf("%w: %q (expected key=value or key!=value)", ErrInvalidTagFilter, expr)
+	}
+
+	return TagFilter{
+		Key:    key,
+		Value:  strings.TrimSpace(value),
+		Negate: negate,
+	}, nil
+}
+
+// ParseTagFilters parses a list of tag filter expressions
+func ParseTagFilters(exprs []string) ([]TagFilter, error) {
+	filters := make([]TagFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := ParseTagFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// Match reports whether the tags satisfy the filter.
+// A negated filter also matches when the tag is missing.
+func (f TagFilter) Match(tags map[string]string) bool {
+	value, ok := tags[f.Key]
+	matches := ok && value == f.Value
+	if f.Negate {
+		return !matches
+	}
+	return matches
+}
+
+// String returns the filter in its expression form
+func (f TagFilter) String() string {
+	if f.Negate {
+		return f.Key + "!=" + f.Value
+	}
+	return f.Key + "=" + f.Value
+}
+
+// applyTagFilters keeps only the data sources matching every tag filter
+func applyTagFilters(dataSources []storage.DataSource, filters []TagFilter) []storage.DataSource {
+	if len(filters) == 0 {
+		return dataSources
+	}
+
+	log.Debug().
+		Stringers("filters", tagFilterStringers(filters)).
+		Int("source_count", len(dataSources)).
+		Msg("Applying tag filters")
+
+	filteredDataSources := make([]storage.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		if matchesTagFilters(ds.Tags, filters) {
+			filteredDataSources = append(filteredDataSources, ds)
+		}
+	}
+
+	log.Debug().
+		Int("filtered_out", len(dataSources)-len(filteredDataSources)).
+		Int("remaining", len(filteredDataSources)).
+		Msg("Tag filtering complete")
+
+	return filteredDataSources
+}
+
+// matchesTagFilters reports whether the tags satisfy all the filters
+func matchesTagFilters(tags map[string]string, filters []TagFilter) bool {
+	for _, filter := range filters {
+		if !filter.Match(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagFilterStringers adapts the filters for structured logging
+func tagFilterStringers(filters []TagFilter) []fmt.Stringer {
+	stringers := make([]fmt.Stringer, len(filters))
+	for i, filter := range filters {
+		stringers[i] = filter
+	}
+	return stringers
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    TagFilter
+		wantErr bool
+	}{
+		{expr: "env=prod", want: TagFilter{Key: "env", Value: "prod"}},
+		{expr: "env!=prod", want: TagFilter{Key: "env", Value: "prod", Negate: true}},
+		{expr: " env = prod ", want: TagFilter{Key: "env", Value: "prod"}},
+		{expr: "k=", want: TagFilter{Key: "k", Value: ""}},
+		{expr: "url=a=b", want: TagFilter{Key: "url", Value: "a=b"}},
+		{expr: "=v", wantErr: true},
+		{expr: "!=v", wantErr: true},
+		{expr: "env", wantErr: true},
+		{expr: "", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			filter, err := ParseTagFilter(tc.expr)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTagFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, filter)
+		})
+	}
+}
+
+func TestTagFilter_Match(t *testing.T) {
+	tags := map[string]string{"env": "prod", "team": "payments", "empty": ""}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"env=prod", true},
+		{"env=staging", false},
+		{"env!=staging", true},
+		{"env!=prod", false},
+		{"region=eu", false},
+		{"region!=eu", true}, // A negated filter matches resources without the tag
+		{"empty=", true},
+		{"region=", false}, // A missing tag isn't an empty one
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			filter, err := ParseTagFilter(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, filter.Match(tags))
+		})
+	}
+}
+
+func TestApplyTagFilters(t *testing.T) {
+	dataSources := []storage.DataSource{
+		{Name: "payments-db", Tags: map[string]string{"env": "prod", "team": "payments"}},
+		{Name: "orders-db", Tags: map[string]string{"env": "prod", "team": "orders"}},
+		{Name: "staging-db", Tags: map[string]string{"env": "staging", "team": "payments"}},
+		{Name: "untagged"},
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{"none", nil, []string{"payments-db", "orders-db", "staging-db", "untagged"}},
+		{"single", []string{"env=prod"}, []string{"payments-db", "orders-db"}},
+		{"all must match", []string{"env=prod", "team!=payments"}, []string{"orders-db"}},
+		{"negated only", []string{"team!=payments"}, []string{"orders-db", "untagged"}},
+		{"contradicting", []string{"env=prod", "env=staging"}, []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filters, err := ParseTagFilters(tc.filters)
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, ds := range applyTagFilters(dataSources, filters) {
+				names = append(names, ds.Name)
+			}
+			assert.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func TestParseTagFilters_Invalid(t *testing.T) {
+	_, err := ParseTagFilters([]string{"env=prod", "team"})
+	assert.ErrorIs(t, err, ErrInvalidTagFilter)
+}
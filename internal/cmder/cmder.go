@@ -164,6 +164,11 @@ func (r *CommandRunner) RunCommandWithContext(ctx context.Context, opts ...Comma
 	// Execute the command
 	err := cmd.Run()
 	if err != nil {
+		// Let the error parser tell a killed command from a failed one
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+
 		output := r.outputBuffer.String()
 		log.Error().
 			Err(err).
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+)
+
+// pingTimeout bounds the check of whether the daemon is running
+const pingTimeout = 500 * time.Millisecond
+
+// Client sends requests to the daemon listening on a Unix socket
+type Client struct {
+	path string
+}
+
+// NewClient creates a client of the daemon listening on the socket at path
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Running reports whether a daemon answers on the socket
+func (c *Client) Running(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	_, err := c.Status(ctx)
+	return err == nil
+}
+
+// List returns the resources the daemon keeps in memory
+func (c *Client) List(ctx context.Context) ([]sdm.Resource, error) {
+	resp, err := c.call(ctx, Request{Method: MethodList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+// Connect connects to the data source through the daemon
+func (c *Client) Connect(ctx context.Context, name string) error {
+	_, err := c.call(ctx, Request{Method: MethodConnect, Name: name})
+	return err
+}
+
+// Disconnect disconnects from the data source through the daemon
+func (c *Client) Disconnect(ctx context.Context, name string) error {
+	_, err := c.call(ctx, Request{Method: MethodDisconnect, Name: name})
+	return err
+}
+
+// Status returns the state of the daemon
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	resp, err := c.call(ctx, Request{Method: MethodStatus})
+	if err != nil {
+		return Status{}, err
+	}
+	if resp.Status == nil {
+		return Status{}, fmt.Errorf("daemon sent no status")
+	}
+	return *resp.Status, nil
+}
+
+// Sync makes the daemon refresh the resources now and returns its state afterwards
+func (c *Client) Sync(ctx context.Context) (Status, error) {
+	resp, err := c.call(ctx, Request{Method: MethodSync})
+	if err != nil {
+		return Status{}, err
+	}
+	if resp.Status == nil {
+		return Status{}, fmt.Errorf("daemon sent no status")
+	}
+	return *resp.Status, nil
+}
+
+// call sends the request on a new connection and waits for the response. Failing to reach
+// the daemon returns ErrNotRunning, errors of the request keep their SDM error code.
+func (c *Client) call(ctx context.Context, req Request) (Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return Response{}, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+
+	// Give up on the response when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send %s request: %w", req.Method, err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return Response{}, fmt.Errorf("%s request canceled: %w", req.Method, ctx.Err())
+		}
+		return Response{}, fmt.Errorf("failed to read %s response: %w", req.Method, err)
+	}
+
+	if err := resp.Error.err(); err != nil {
+		return Response{}, err
+	}
+	return resp, nil
+}
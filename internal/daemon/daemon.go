@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+)
+
+// DefaultInterval is how often the daemon refreshes the resources
+const DefaultInterval = time.Minute
+
+var (
+	// ErrNotRunning indicates that no daemon listens on the socket
+	ErrNotRunning = errors.New("daemon is not running")
+	// ErrAlreadyRunning indicates that another daemon already listens on the socket
+	ErrAlreadyRunning = errors.New("daemon is already running")
+	// ErrUnknownMethod is returned for requests the daemon doesn't serve
+	ErrUnknownMethod = errors.New("unknown method")
+)
+
+// Methods served by the daemon
+const (
+	MethodList       = "list"
+	MethodConnect    = "connect"
+	MethodDisconnect = "disconnect"
+	MethodStatus     = "status"
+	MethodSync       = "sync"
+)
+
+// Backend runs the SDM commands of the daemon and persists the resources it fetches
+type Backend interface {
+	Status(ctx context.Context) ([]sdm.Resource, error)
+	Connect(ctx context.Context, name string) error
+	Disconnect(ctx context.Context, name string) error
+	Store(resources []sdm.Resource) error
+}
+
+// Request is sent by clients, one per connection
+type Request struct {
+	Method string `json:"method"`
+	Name   string `json:"name,omitempty"` // Data source to connect to or disconnect from
+}
+
+// Response answers a request
+type Response struct {
+	Error     *Error         `json:"error,omitempty"`
+	Resources []sdm.Resource `json:"resources"` // Kept when empty, which differs from unknown
+	Status    *Status        `json:"status,omitempty"`
+}
+
+// Error carries a failure over the socket, keeping the code of SDM errors
+type Error struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Status describes the state of the daemon
+type Status struct {
+	Account   string    `json:"account"`
+	Resources int       `json:"resources"`
+	SyncedAt  time.Time `json:"syncedAt"`
+	SyncError string    `json:"syncError,omitempty"`
+}
+
+// SocketPath returns the path of the socket of the account's daemon in $XDG_RUNTIME_DIR
+func SocketPath(account string) (string, error) {
+	path, err := xdg.RuntimeFile(fmt.Sprintf("sdm-ui/%s.sock", account))
+	if err != nil {
+		return "", fmt.Errorf("could not locate the daemon socket: %w", err)
+	}
+	return path, nil
+}
+
+// newError converts an error for the response, keeping the code of SDM errors
+func newError(err error) *Error {
+	var sdmErr sdm.SDMError
+	if errors.As(err, &sdmErr) {
+		return &Error{Code: sdmErr.Code.String(), Message: sdmErr.Msg}
+	}
+	return &Error{Message: err.Error()}
+}
+
+// err converts the error back, as an SDM error when it carries a code
+func (e *Error) err() error {
+	if e == nil {
+		return nil
+	}
+	if e.Code != "" {
+		if code, err := sdm.ParseErrorCode(e.Code); err == nil {
+			return sdm.SDMError{Code: code, Msg: e.Message}
+		}
+	}
+	return errors.New(e.Message)
+}
@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend serves fixed resources and records the calls of the daemon
+type fakeBackend struct {
+	mu         sync.Mutex
+	resources  []sdm.Resource
+	statusErr  error
+	connectErr error
+	statuses   int
+	connected  []string
+	stored     [][]sdm.Resource
+}
+
+func (b *fakeBackend) Status(ctx context.Context) ([]sdm.Resource, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.statuses++
+	return b.resources, b.statusErr
+}
+
+func (b *fakeBackend) Connect(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connectErr != nil {
+		return b.connectErr
+	}
+	b.connected = append(b.connected, name)
+
+	// Report the connection on the next status, like sdm does
+	resources := make([]sdm.Resource, len(b.resources))
+	for i, r := range b.resources {
+		if r.Name == name {
+			r.Connected = true
+			r.ConnectionStatus = "connected"
+		}
+		resources[i] = r
+	}
+	b.resources = resources
+	return nil
+}
+
+func (b *fakeBackend) Disconnect(ctx context.Context, name string) error {
+	return nil
+}
+
+func (b *fakeBackend) Store(resources []sdm.Resource) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stored = append(b.stored, resources)
+	return nil
+}
+
+// startServer serves the backend on a socket in a temporary directory until the test ends
+func startServer(t *testing.T, backend Backend) *Client {
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer("me@example.com", backend, WithInterval(time.Hour)).Serve(ctx, listener)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	client := NewClient(path)
+	require.Eventually(t, func() bool {
+		status, err := client.Status(context.Background())
+		return err == nil && !status.SyncedAt.IsZero()
+	}, 2*time.Second, 10*time.Millisecond, "the daemon should refresh the resources on start")
+	return client
+}
+
+func TestServer_List(t *testing.T) {
+	backend := &fakeBackend{resources: []sdm.Resource{
+		{Name: "payments-db", Type: "postgres", Address: "localhost:10001", Tags: sdm.Tags{"env": "prod"}},
+		{Name: "grafana", Type: "httpNoAuth", Connected: true, ConnectionStatus: "connected"},
+	}}
+	client := startServer(t, backend)
+
+	resources, err := client.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, backend.resources, resources)
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	assert.Equal(t, 1, backend.statuses, "listing should be served from memory")
+	assert.Len(t, backend.stored, 1, "the refreshed resources should be stored")
+}
+
+func TestServer_ListEmpty(t *testing.T) {
+	client := startServer(t, &fakeBackend{resources: []sdm.Resource{}})
+
+	resources, err := client.List(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, resources, "an account without resources should not look like a failed status")
+	assert.Empty(t, resources)
+}
+
+func TestServer_Connect(t *testing.T) {
+	backend := &fakeBackend{resources: []sdm.Resource{{Name: "payments-db", ConnectionStatus: "not connected"}}}
+	client := startServer(t, backend)
+
+	require.NoError(t, client.Connect(context.Background(), "payments-db"))
+
+	resources, err := client.List(context.Background())
+	require.NoError(t, err)
+	assert.True(t, resources[0].Connected, "the connection should be visible right away")
+	assert.Equal(t, "connected", resources[0].ConnectionStatus)
+
+	backend.mu.Lock()
+	assert.Equal(t, []string{"payments-db"}, backend.connected)
+	backend.mu.Unlock()
+
+	assert.Error(t, client.Connect(context.Background(), ""))
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	backend := &fakeBackend{connectErr: sdm.SDMError{Code: sdm.Unauthorized, Msg: "You are not authenticated"}}
+	client := startServer(t, backend)
+
+	err := client.Connect(context.Background(), "payments-db")
+
+	var sdmErr sdm.SDMError
+	require.ErrorAs(t, err, &sdmErr, "SDM errors should keep their code over the socket")
+	assert.Equal(t, sdm.Unauthorized, sdmErr.Code)
+	assert.Equal(t, "You are not authenticated", sdmErr.Msg)
+}
+
+func TestServer_Status(t *testing.T) {
+	backend := &fakeBackend{resources: []sdm.Resource{{Name: "payments-db"}}}
+	client := startServer(t, backend)
+
+	status, err := client.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "me@example.com", status.Account)
+	assert.Equal(t, 1, status.Resources)
+	assert.Empty(t, status.SyncError)
+
+	backend.mu.Lock()
+	backend.statusErr = errors.New("listener stopped")
+	backend.mu.Unlock()
+
+	_, err = client.Sync(context.Background())
+	assert.ErrorContains(t, err, "listener stopped")
+
+	status, err = client.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Resources, "the last resources should be kept when a refresh fails")
+	assert.Equal(t, "listener stopped", status.SyncError)
+}
+
+func TestClient_NotRunning(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "daemon.sock"))
+
+	assert.False(t, client.Running(context.Background()))
+
+	_, err := client.List(context.Background())
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestListen_AlreadyRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer("me@example.com", &fakeBackend{}).Serve(ctx, listener)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		return NewClient(path).Running(context.Background())
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = Listen(path)
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+}
@@ -0,0 +1,275 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/marianozunino/sdm-ui/internal/sdm"
+	"github.com/rs/zerolog/log"
+)
+
+// Server refreshes the resources in the background and serves them over a Unix socket
+type Server struct {
+	account  string
+	backend  Backend
+	interval time.Duration
+
+	syncMu sync.Mutex     // serializes refreshes
+	wg     sync.WaitGroup // tracks the goroutines started by Serve
+
+	mu        sync.RWMutex // guards the fields below
+	resources []sdm.Resource
+	syncedAt  time.Time
+	syncErr   error
+}
+
+// ServerOption defines a function type that modifies Server configuration
+type ServerOption func(*Server)
+
+// WithInterval sets how often the resources are refreshed
+func WithInterval(interval time.Duration) ServerOption {
+	return func(s *Server) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// NewServer creates a daemon serving the resources of the account
+func NewServer(account string, backend Backend, opts ...ServerOption) *Server {
+	s := &Server{
+		account:  account,
+		backend:  backend,
+		interval: DefaultInterval,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Listen opens the socket at path, replacing the one of a daemon that is no longer running
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if NewClient(path).Running(context.Background()) {
+			return nil, fmt.Errorf("%w on %s", ErrAlreadyRunning, path)
+		}
+		log.Debug().Str("path", path).Msg("Removing stale daemon socket")
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// Serve refreshes the resources every interval and answers the requests received by the
+// listener until the context is done. The listener is closed when Serve returns.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer s.wg.Wait()
+
+	// Unblock Accept when the context is done
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.refreshLoop(ctx)
+	}()
+
+	log.Info().Str("address", listener.Addr().String()).Msg("Daemon listening")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+// refreshLoop refreshes the resources right away and then every interval
+func (s *Server) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Failed to refresh resources")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the resources, keeps them in memory and persists them
+func (s *Server) Refresh(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	log.Debug().Msg("Refreshing resources")
+
+	resources, err := s.backend.Status(ctx)
+
+	s.mu.Lock()
+	s.syncErr = err
+	if err == nil {
+		s.resources = resources
+		s.syncedAt = time.Now()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Keep serving from memory when the cache can't be written, e.g. while a client holds it
+	if err := s.backend.Store(resources); err != nil {
+		log.Warn().Err(err).Msg("Failed to store resources")
+	}
+
+	log.Debug().Int("resources", len(resources)).Msg("Resources refreshed")
+	return nil
+}
+
+// handle answers the request of a connection
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Debug().Err(err).Msg("Failed to decode request")
+		return
+	}
+
+	log.Debug().Str("method", req.Method).Str("name", req.Name).Msg("Handling request")
+
+	resp := s.dispatch(ctx, req)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Debug().Err(err).Str("method", req.Method).Msg("Failed to send response")
+	}
+}
+
+// dispatch runs the method of the request
+func (s *Server) dispatch(ctx context.Context, req Request) Response {
+	switch req.Method {
+	case MethodList:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.syncedAt.IsZero() && s.syncErr != nil {
+			return Response{Error: newError(s.syncErr)}
+		}
+		return Response{Resources: s.resources}
+
+	case MethodStatus:
+		return Response{Status: s.status()}
+
+	case MethodSync:
+		if err := s.Refresh(ctx); err != nil {
+			return Response{Error: newError(err)}
+		}
+		return Response{Status: s.status()}
+
+	case MethodConnect, MethodDisconnect:
+		if req.Name == "" {
+			return Response{Error: &Error{Message: "missing data source name"}}
+		}
+
+		connect := req.Method == MethodConnect
+		run := s.backend.Disconnect
+		if connect {
+			run = s.backend.Connect
+		}
+		if err := run(ctx, req.Name); err != nil {
+			return Response{Error: newError(err)}
+		}
+		s.setConnected(req.Name, connect)
+
+		// The cache is brought up to date in the background, the state in memory already is
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Failed to refresh resources")
+			}
+		}()
+		return Response{}
+
+	default:
+		return Response{Error: &Error{Message: fmt.Sprintf("%v: %q", ErrUnknownMethod, req.Method)}}
+	}
+}
+
+// status returns the state of the daemon
+func (s *Server) status() *Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := &Status{
+		Account:   s.account,
+		Resources: len(s.resources),
+		SyncedAt:  s.syncedAt,
+	}
+	if s.syncErr != nil {
+		status.SyncError = s.syncErr.Error()
+	}
+	return status
+}
+
+// setConnected updates the connection state of the resource in memory
+func (s *Server) setConnected(name string, connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := slices.IndexFunc(s.resources, func(r sdm.Resource) bool {
+		return r.Name == name
+	})
+	if idx < 0 {
+		return
+	}
+
+	// Copy the slice, clients may still be encoding the previous one
+	resources := slices.Clone(s.resources)
+	resources[idx].Connected = connected
+	resources[idx].ConnectionStatus = "not connected"
+	if connected {
+		resources[idx].ConnectionStatus = "connected"
+	}
+	s.resources = resources
+}
@@ -0,0 +1,278 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+)
+
+// ErrInvalidRule indicates that a filter rule could not be compiled
+var ErrInvalidRule = errors.New("invalid filter rule")
+
+// Action is what happens to a data source matched by a rule
+type Action string
+
+// Available rule actions
+const (
+	Include Action = "include"
+	Exclude Action = "exclude"
+)
+
+// RuleConfig is the configuration form of a rule. Every field is a regular expression
+// and all the fields that are set must match for the rule to apply. Tag keys are matched
+// regardless of case since the config file lowercases them.
+type RuleConfig struct {
+	Action  string            `mapstructure:"action"`
+	Name    string            `mapstructure:"name"`
+	Type    string            `mapstructure:"type"`
+	Address string            `mapstructure:"address"`
+	Status  string            `mapstructure:"status"`
+	Tags    map[string]string `mapstructure:"tags"`
+}
+
+// Rule is a compiled filter rule
+type Rule struct {
+	Action Action
+	Source string // where the rule was declared, e.g. "filterRules[0]"
+
+	name    *regexp.Regexp
+	typ     *regexp.Regexp
+	address *regexp.Regexp
+	status  *regexp.Regexp
+	tags    map[string]*regexp.Regexp // keyed by lowercased tag key
+}
+
+// Compile validates the rule configuration and compiles its patterns
+func Compile(source string, cfg RuleConfig) (Rule, error) {
+	rule := Rule{
+		Action: Action(strings.ToLower(strings.TrimSpace(cfg.Action))),
+		Source: source,
+	}
+
+	if rule.Action != Include && rule.Action != Exclude {
+		return Rule{}, fmt.Errorf("%w: %s: action must be %q or %q, got %q", ErrInvalidRule, source, Include, Exclude, cfg.Action)
+	}
+
+	var err error
+	fields := []struct {
+		field   string
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{"name", cfg.Name, &rule.name},
+		{"type", cfg.Type, &rule.typ},
+		{"address", cfg.Address, &rule.address},
+		{"status", cfg.Status, &rule.status},
+	}
+	for _, f := range fields {
+		if *f.target, err = compilePattern(source, f.field, f.pattern); err != nil {
+			return Rule{}, err
+		}
+	}
+
+	if len(cfg.Tags) > 0 {
+		rule.tags = make(map[string]*regexp.Regexp, len(cfg.Tags))
+		for key, pattern := range cfg.Tags {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return Rule{}, fmt.Errorf("%w: %s: tags.%s: %v", ErrInvalidRule, source, key, err)
+			}
+			rule.tags[strings.ToLower(key)] = re
+		}
+	}
+
+	return rule, nil
+}
+
+// compilePattern compiles an optional rule pattern
+func compilePattern(source, field, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s: %v", ErrInvalidRule, source, field, err)
+	}
+	return re, nil
+}
+
+// Match reports whether the data source satisfies every pattern of the rule.
+// A rule without patterns matches everything.
+func (r Rule) Match(ds storage.DataSource) bool {
+	if r.name != nil && !r.name.MatchString(ds.Name) {
+		return false
+	}
+	if r.typ != nil && !r.typ.MatchString(ds.Type) {
+		return false
+	}
+	if r.address != nil && !r.address.MatchString(ds.Address) {
+		return false
+	}
+	if r.status != nil && !r.status.MatchString(ds.Status) {
+		return false
+	}
+	for key, re := range r.tags {
+		if !matchTag(ds.Tags, key, re) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTag reports whether a tag of the data source, whatever the case of its key, has a value
+// matching the pattern
+func matchTag(tags map[string]string, key string, re *regexp.Regexp) bool {
+	for tagKey, value := range tags {
+		if strings.EqualFold(tagKey, key) && re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the rule, e.g. "filterRules[1]: exclude name=/prod/ tags.team=/payments/"
+func (r Rule) String() string {
+	var parts []string
+	for _, f := range []struct {
+		field string
+		re    *regexp.Regexp
+	}{
+		{"name", r.name},
+		{"type", r.typ},
+		{"address", r.address},
+		{"status", r.status},
+	} {
+		if f.re != nil {
+			parts = append(parts, fmt.Sprintf("%s=/%s/", f.field, f.re))
+		}
+	}
+
+	keys := make([]string, 0, len(r.tags))
+	for key := range r.tags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("tags.%s=/%s/", key, r.tags[key]))
+	}
+
+	if len(parts) == 0 {
+		parts = append(parts, "*")
+	}
+
+	return fmt.Sprintf("%s: %s %s", r.Source, r.Action, strings.Join(parts, " "))
+}
+
+// Decision is the outcome of evaluating a data source against a filter
+type Decision struct {
+	Included bool
+	Rule     *Rule // nil when no rule matched and the default applied
+}
+
+// Reason describes why the decision was taken
+func (d Decision) Reason() string {
+	if d.Rule != nil {
+		return d.Rule.String()
+	}
+	if d.Included {
+		return "default: include"
+	}
+	return "default: exclude (no include rule matched)"
+}
+
+// Filter evaluates data sources against an ordered list of rules.
+// The first matching rule wins. When no rule matches, data sources are
+// excluded if at least one include rule exists and included otherwise.
+type Filter struct {
+	rules          []Rule
+	defaultInclude bool
+}
+
+// New creates a filter from compiled rules, evaluated in the given order
+func New(rules ...Rule) *Filter {
+	f := &Filter{
+		rules:          rules,
+		defaultInclude: true,
+	}
+
+	for _, rule := range rules {
+		if rule.Action == Include {
+			f.defaultInclude = false
+			break
+		}
+	}
+
+	return f
+}
+
+// Empty reports whether the filter has no rules
+func (f *Filter) Empty() bool {
+	return len(f.rules) == 0
+}
+
+// Rules returns the rules of the filter in evaluation order
+func (f *Filter) Rules() []Rule {
+	return f.rules
+}
+
+// Evaluate returns the decision for a single data source
+func (f *Filter) Evaluate(ds storage.DataSource) Decision {
+	for i := range f.rules {
+		if f.rules[i].Match(ds) {
+			return Decision{
+				Included: f.rules[i].Action == Include,
+				Rule:     &f.rules[i],
+			}
+		}
+	}
+	return Decision{Included: f.defaultInclude}
+}
+
+// Apply returns the data sources that the filter includes
+func (f *Filter) Apply(dataSources []storage.DataSource) []storage.DataSource {
+	if f.Empty() {
+		return dataSources
+	}
+
+	filtered := make([]storage.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		if f.Evaluate(ds).Included {
+			filtered = append(filtered, ds)
+		}
+	}
+	return filtered
+}
+
+// BlacklistRules converts name blacklist patterns into exclude rules
+func BlacklistRules(patterns []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(patterns))
+	for i, pattern := range patterns {
+		rule, err := Compile(fmt.Sprintf("blacklistPatterns[%d]", i), RuleConfig{
+			Action: string(Exclude),
+			Name:   pattern,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// CompileRules compiles the configured rules in order
+func CompileRules(configs []RuleConfig) ([]Rule, error) {
+	rules := make([]Rule, 0, len(configs))
+	for i, cfg := range configs {
+		rule, err := Compile(fmt.Sprintf("filterRules[%d]", i), cfg)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,136 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/marianozunino/sdm-ui/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDataSources = []storage.DataSource{
+	{Name: "payments-db-prod", Type: "postgres", Address: "localhost:10001", Status: "connected", Tags: map[string]string{"env": "prod", "team": "payments"}},
+	{Name: "payments-cache-staging", Type: "redis", Address: "localhost:10002", Status: "not connected", Tags: map[string]string{"env": "staging", "team": "payments"}},
+	{Name: "grafana", Type: "httpNoAuth", Address: "http://grafana.example.com", Status: "not connected"},
+}
+
+func names(dataSources []storage.DataSource) []string {
+	result := make([]string, 0, len(dataSources))
+	for _, ds := range dataSources {
+		result = append(result, ds.Name)
+	}
+	return result
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RuleConfig
+	}{
+		{name: "MissingAction", cfg: RuleConfig{Name: "prod"}},
+		{name: "UnknownAction", cfg: RuleConfig{Action: "drop", Name: "prod"}},
+		{name: "InvalidName", cfg: RuleConfig{Action: "exclude", Name: "*rds*"}},
+		{name: "InvalidTag", cfg: RuleConfig{Action: "include", Tags: map[string]string{"env": "(prod"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile("filterRules[0]", tc.cfg)
+			require.ErrorIs(t, err, ErrInvalidRule)
+			assert.Contains(t, err.Error(), "filterRules[0]")
+		})
+	}
+}
+
+func TestFilter_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []RuleConfig
+		expected []string
+	}{
+		{
+			name:     "NoRules",
+			expected: []string{"payments-db-prod", "payments-cache-staging", "grafana"},
+		},
+		{
+			name:     "ExcludeOnly",
+			rules:    []RuleConfig{{Action: "exclude", Tags: map[string]string{"env": "^prod$"}}},
+			expected: []string{"payments-cache-staging", "grafana"},
+		},
+		{
+			name:     "IncludeOnlyHidesTheRest",
+			rules:    []RuleConfig{{Action: "include", Type: "postgres|redis"}},
+			expected: []string{"payments-db-prod", "payments-cache-staging"},
+		},
+		{
+			name: "FirstMatchWins",
+			rules: []RuleConfig{
+				{Action: "include", Name: "prod", Status: "^connected$"},
+				{Action: "exclude", Tags: map[string]string{"team": "payments"}},
+				{Action: "include"},
+			},
+			expected: []string{"payments-db-prod", "grafana"},
+		},
+		{
+			name:     "AddressMatch",
+			rules:    []RuleConfig{{Action: "exclude", Address: "^http"}},
+			expected: []string{"payments-db-prod", "payments-cache-staging"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := CompileRules(tc.rules)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, names(New(rules...).Apply(testDataSources)))
+		})
+	}
+}
+
+func TestFilter_Evaluate(t *testing.T) {
+	blacklist, err := BlacklistRules([]string{"grafana"})
+	require.NoError(t, err)
+
+	rules, err := CompileRules([]RuleConfig{{Action: "exclude", Type: "redis", Tags: map[string]string{"env": "staging"}}})
+	require.NoError(t, err)
+
+	f := New(append(blacklist, rules...)...)
+
+	decision := f.Evaluate(testDataSources[2])
+	assert.False(t, decision.Included)
+	assert.Equal(t, "blacklistPatterns[0]: exclude name=/grafana/", decision.Reason())
+
+	decision = f.Evaluate(testDataSources[1])
+	assert.False(t, decision.Included)
+	assert.Equal(t, "filterRules[0]: exclude type=/redis/ tags.env=/staging/", decision.Reason())
+
+	decision = f.Evaluate(testDataSources[0])
+	assert.True(t, decision.Included)
+	assert.Nil(t, decision.Rule)
+	assert.Equal(t, "default: include", decision.Reason())
+}
+
+func TestRule_MatchTagKeyCase(t *testing.T) {
+	ds := storage.DataSource{Name: "payments-db", Tags: map[string]string{"Env": "Prod", "team": "payments"}}
+
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected bool
+	}{
+		// The config file lowercases the keys of the rules, not the tags of the resources
+		{"LowercasedKey", map[string]string{"env": "^Prod$"}, true},
+		{"MixedCaseKey", map[string]string{"ENV": "^Prod$", "Team": "payments"}, true},
+		{"ValueCaseMatters", map[string]string{"env": "^prod$"}, false},
+		{"MissingTag", map[string]string{"region": ".*"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Compile("filterRules[0]", RuleConfig{Action: "include", Tags: tc.tags})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rule.Match(ds))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package libsecret
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Default items of the password manager providers
+const (
+	defaultPassPrefix   = "sdm-ui"
+	default1PasswordRef = "op://Private/StrongDM/password"
+	defaultBitwardenRef = "StrongDM"
+)
+
+// Pass stores secrets in the pass password store, one entry per account
+type Pass struct {
+	exe    string
+	prefix string
+}
+
+func newPass(opts Options) (*Pass, error) {
+	exe, err := lookPath(ProviderPass, "pass")
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := opts.Item
+	if prefix == "" {
+		prefix = defaultPassPrefix
+	}
+	return &Pass{exe: exe, prefix: strings.TrimSuffix(prefix, "/")}, nil
+}
+
+func (p *Pass) entry(email string) string {
+	return p.prefix + "/" + email
+}
+
+func (p *Pass) GetSecret(email string) (string, error) {
+	output, err := run("", nil, p.exe, "show", p.entry(email))
+	if err != nil {
+		return "", err
+	}
+	return firstLine(output), nil
+}
+
+func (p *Pass) SetSecret(email string, secret string) error {
+	_, err := run(secret+"\n", nil, p.exe, "insert", "--multiline", "--force", p.entry(email))
+	return err
+}
+
+func (p *Pass) DeleteSecret(email string) error {
+	_, err := run("", nil, p.exe, "rm", "--force", p.entry(email))
+	return err
+}
+
+// Command reads secrets from the output of a command, leaving storage to the tool it runs.
+// 1Password and Bitwarden are commands with a fixed command line.
+type Command struct {
+	name string
+	exe  string
+	args []string
+}
+
+func newCommand(opts Options) (*Command, error) {
+	if opts.Command == "" {
+		return nil, fmt.Errorf("%s secret provider requires secretProviderOptions.command", ProviderCommand)
+	}
+
+	sh, err := lookPath(ProviderCommand, "sh")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Command{
+		name: ProviderCommand,
+		exe:  sh,
+		args: []string{"-c", opts.Command},
+	}, nil
+}
+
+func new1Password(opts Options) (*Command, error) {
+	exe, err := lookPath(Provider1Password, "op")
+	if err != nil {
+		return nil, err
+	}
+
+	ref := opts.Item
+	if ref == "" {
+		ref = default1PasswordRef
+	}
+
+	return &Command{
+		name: Provider1Password,
+		exe:  exe,
+		args: []string{"read", "--no-newline", ref},
+	}, nil
+}
+
+func newBitwarden(opts Options) (*Command, error) {
+	exe, err := lookPath(ProviderBitwarden, "bw")
+	if err != nil {
+		return nil, err
+	}
+
+	item := opts.Item
+	if item == "" {
+		item = defaultBitwardenRef
+	}
+
+	return &Command{
+		name: ProviderBitwarden,
+		exe:  exe,
+		args: []string{"get", "password", item},
+	}, nil
+}
+
+// GetSecret runs the command with the account in the SDM_EMAIL environment variable
+func (c *Command) GetSecret(email string) (string, error) {
+	output, err := run("", []string{"SDM_EMAIL=" + email}, c.exe, c.args...)
+	if err != nil {
+		return "", fmt.Errorf("%s secret provider failed: %w", c.name, err)
+	}
+
+	secret := firstLine(output)
+	if secret == "" {
+		return "", errors.New(c.name + " secret provider returned an empty secret")
+	}
+	return secret, nil
+}
+
+func (c *Command) SetSecret(email string, secret string) error {
+	return fmt.Errorf("%w: %s", ErrReadOnly, c.name)
+}
+
+func (c *Command) DeleteSecret(email string) error {
+	return fmt.Errorf("%w: %s", ErrReadOnly, c.name)
+}
@@ -0,0 +1,119 @@
+package libsecret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+)
+
+// defaultSecretsDir is where encrypted files are kept when no path is configured
+var defaultSecretsDir = filepath.Join(xdg.DataHome, "sdm-ui", "secrets")
+
+// EncryptedFile stores each secret in a local file encrypted with age or gpg
+type EncryptedFile struct {
+	name    string
+	exe     string
+	dir     string
+	ext     string
+	encrypt func(path string) []string
+	decrypt func(path string) []string
+}
+
+func newAgeFile(opts Options) (*EncryptedFile, error) {
+	if opts.Recipient == "" || opts.Identity == "" {
+		return nil, fmt.Errorf("%s secret provider requires secretProviderOptions.recipient and secretProviderOptions.identity", ProviderAge)
+	}
+
+	exe, err := lookPath(ProviderAge, "age")
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedFile{
+		name: ProviderAge,
+		exe:  exe,
+		dir:  secretsDir(opts),
+		ext:  ".age",
+		encrypt: func(path string) []string {
+			return []string{"--encrypt", "--recipient", opts.Recipient, "--output", path}
+		},
+		decrypt: func(path string) []string {
+			return []string{"--decrypt", "--identity", opts.Identity, path}
+		},
+	}, nil
+}
+
+func newGPGFile(opts Options) (*EncryptedFile, error) {
+	exe, err := lookPath(ProviderGPG, "gpg")
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a recipient the files are encrypted to the default key
+	recipient := []string{"--default-recipient-self"}
+	if opts.Recipient != "" {
+		recipient = []string{"--recipient", opts.Recipient}
+	}
+
+	return &EncryptedFile{
+		name: ProviderGPG,
+		exe:  exe,
+		dir:  secretsDir(opts),
+		ext:  ".gpg",
+		encrypt: func(path string) []string {
+			args := append([]string{"--batch", "--yes", "--quiet", "--encrypt"}, recipient...)
+			return append(args, "--output", path)
+		},
+		decrypt: func(path string) []string {
+			return []string{"--batch", "--quiet", "--decrypt", path}
+		},
+	}, nil
+}
+
+// secretsDir returns the configured directory of the encrypted files
+func secretsDir(opts Options) string {
+	if opts.Path != "" {
+		return opts.Path
+	}
+	return defaultSecretsDir
+}
+
+func (f *EncryptedFile) path(email string) string {
+	return filepath.Join(f.dir, email+f.ext)
+}
+
+func (f *EncryptedFile) GetSecret(email string) (string, error) {
+	path := f.path(email)
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	output, err := run("", nil, f.exe, f.decrypt(path)...)
+	if err != nil {
+		return "", fmt.Errorf("%s failed to decrypt %s: %w", f.name, path, err)
+	}
+	return firstLine(output), nil
+}
+
+func (f *EncryptedFile) SetSecret(email string, secret string) error {
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return err
+	}
+
+	path := f.path(email)
+	if _, err := run(secret+"\n", nil, f.exe, f.encrypt(path)...); err != nil {
+		return fmt.Errorf("%s failed to encrypt %s: %w", f.name, path, err)
+	}
+	return os.Chmod(path, 0o600)
+}
+
+func (f *EncryptedFile) DeleteSecret(email string) error {
+	err := os.Remove(f.path(email))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
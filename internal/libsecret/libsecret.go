@@ -1,21 +1,126 @@
 package libsecret
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
 	libsecret "github.com/zalando/go-keyring"
 )
 
 const service_key = "sdm-credential"
 
-type Keyring struct{}
+var (
+	// ErrReadOnly is returned when a provider can't store or delete secrets
+	ErrReadOnly = errors.New("secret provider is read-only")
+	// ErrUnknownProvider is returned for unsupported secret providers
+	ErrUnknownProvider = errors.New("unknown secret provider")
+)
+
+// Keyring stores the passwords of SDM accounts
+type Keyring interface {
+	GetSecret(email string) (string, error)
+	SetSecret(email string, secret string) error
+	DeleteSecret(email string) error
+}
+
+// Available secret providers
+const (
+	ProviderKeyring   = "keyring"
+	ProviderPass      = "pass"
+	Provider1Password = "1password"
+	ProviderBitwarden = "bitwarden"
+	ProviderCommand   = "command"
+	ProviderAge       = "age"
+	ProviderGPG       = "gpg"
+)
+
+// Providers lists the available secret providers
+var Providers = []string{ProviderKeyring, ProviderPass, Provider1Password, ProviderBitwarden, ProviderCommand, ProviderAge, ProviderGPG}
+
+// Options configures the secret providers, each one using the fields it needs
+type Options struct {
+	Command   string `mapstructure:"command"`   // Shell command printing the password, for command
+	Item      string `mapstructure:"item"`      // pass entry prefix, 1Password secret reference or Bitwarden item
+	Path      string `mapstructure:"path"`      // Directory of the encrypted files, for age and gpg
+	Recipient string `mapstructure:"recipient"` // Key the files are encrypted to, for age and gpg
+	Identity  string `mapstructure:"identity"`  // Identity file used to decrypt, for age
+}
+
+// New creates the secret provider with the given name, the system keyring when empty
+func New(provider string, opts Options) (Keyring, error) {
+	switch provider {
+	case "", ProviderKeyring:
+		return &SystemKeyring{}, nil
+	case ProviderPass:
+		return newPass(opts)
+	case Provider1Password:
+		return new1Password(opts)
+	case ProviderBitwarden:
+		return newBitwarden(opts)
+	case ProviderCommand:
+		return newCommand(opts)
+	case ProviderAge:
+		return newAgeFile(opts)
+	case ProviderGPG:
+		return newGPGFile(opts)
+	default:
+		return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownProvider, provider, strings.Join(Providers, ", "))
+	}
+}
 
-func (k *Keyring) GetSecret(email string) (string, error) {
+// SystemKeyring stores secrets in the system keyring through the Secret Service
+type SystemKeyring struct{}
+
+func (k *SystemKeyring) GetSecret(email string) (string, error) {
 	return libsecret.Get(service_key, email)
 }
 
-func (k *Keyring) SetSecret(email string, secret string) error {
+func (k *SystemKeyring) SetSecret(email string, secret string) error {
 	return libsecret.Set(service_key, email, secret)
 }
 
-func (k *Keyring) DeleteSecret(email string) error {
+func (k *SystemKeyring) DeleteSecret(email string) error {
 	return libsecret.Delete(service_key, email)
 }
+
+// lookPath finds the executable of a provider
+func lookPath(provider, name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s secret provider requires %s: %w", provider, name, err)
+	}
+	return path, nil
+}
+
+// run executes a command with the given standard input and extra environment and returns its
+// trimmed output. The standard error is included in the returned error.
+func run(stdin string, env []string, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// firstLine returns the first line of a command output, where password managers print the password
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(output, "\n")
+	return strings.TrimRight(line, "\r")
+}
@@ -0,0 +1,101 @@
+package libsecret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		opts     Options
+		wantErr  string
+	}{
+		{name: "default", provider: ""},
+		{name: "keyring", provider: ProviderKeyring},
+		{name: "command", provider: ProviderCommand, opts: Options{Command: "echo secret"}},
+		{name: "command without command", provider: ProviderCommand, wantErr: "requires secretProviderOptions.command"},
+		{name: "age without identity", provider: ProviderAge, opts: Options{Recipient: "age1xyz"}, wantErr: "requires secretProviderOptions.recipient and secretProviderOptions.identity"},
+		{name: "unknown", provider: "vault", wantErr: "unknown secret provider: vault"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := New(tt.provider, tt.opts)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, keyring)
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	keyring, err := New(ProviderCommand, Options{Command: `printf '%s-secret\nsecond line\n' "$SDM_EMAIL"`})
+	require.NoError(t, err)
+
+	secret, err := keyring.GetSecret("user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com-secret", secret)
+
+	assert.ErrorIs(t, keyring.SetSecret("user@example.com", "secret"), ErrReadOnly)
+	assert.ErrorIs(t, keyring.DeleteSecret("user@example.com"), ErrReadOnly)
+}
+
+func TestCommand_Failure(t *testing.T) {
+	keyring, err := New(ProviderCommand, Options{Command: "echo locked >&2; exit 1"})
+	require.NoError(t, err)
+
+	_, err = keyring.GetSecret("user@example.com")
+	assert.ErrorContains(t, err, "command secret provider failed")
+	assert.ErrorContains(t, err, "locked")
+
+	keyring, err = New(ProviderCommand, Options{Command: "true"})
+	require.NoError(t, err)
+
+	_, err = keyring.GetSecret("user@example.com")
+	assert.ErrorContains(t, err, "empty secret")
+}
+
+func TestEncryptedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// Stand-in cipher storing the plain text, enough to check the file handling
+	script := filepath.Join(dir, "fake-cipher")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nif [ \"$1\" = enc ]; then cat > \"$2\"; else cat \"$2\"; fi\n"), 0o755))
+
+	keyring := &EncryptedFile{
+		name:    "fake",
+		exe:     script,
+		dir:     filepath.Join(dir, "secrets"),
+		ext:     ".enc",
+		encrypt: func(path string) []string { return []string{"enc", path} },
+		decrypt: func(path string) []string { return []string{"dec", path} },
+	}
+
+	_, err := keyring.GetSecret("user@example.com")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, keyring.SetSecret("user@example.com", "hunter2"))
+
+	info, err := os.Stat(filepath.Join(dir, "secrets", "user@example.com.enc"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	secret, err := keyring.GetSecret("user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	require.NoError(t, keyring.DeleteSecret("user@example.com"))
+	require.NoError(t, keyring.DeleteSecret("user@example.com"))
+
+	_, err = keyring.GetSecret("user@example.com")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
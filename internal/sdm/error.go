@@ -1,6 +1,8 @@
 package sdm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -18,6 +20,7 @@ const (
 	ConnectionFailed
 	PermissionDenied
 	Unknown
+	Timeout // Appended to keep the values of the codes above stable
 )
 
 // String returns a string representation of the error code
@@ -33,6 +36,8 @@ func (c SDMErrorCode) String() string {
 		return "ConnectionFailed"
 	case PermissionDenied:
 		return "PermissionDenied"
+	case Timeout:
+		return "Timeout"
 	default:
 		return "Unknown"
 	}
@@ -86,6 +91,15 @@ func parseSdmError(output string, err error) error {
 		{"Timed out", ConnectionFailed},
 	}
 
+	// The command was killed when its deadline expired
+	if errors.Is(err, context.DeadlineExceeded) {
+		return SDMError{
+			Code: Timeout,
+			Msg:  "command timed out",
+			Err:  err,
+		}
+	}
+
 	// Check for known error patterns
 	for _, matcher := range errorMatchers {
 		if strings.Contains(output, matcher.pattern) {
@@ -0,0 +1,142 @@
+package sdm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Tags holds the key/value pairs attached to a resource
+type Tags map[string]string
+
+// ParseTags parses a comma separated tag list such as "env=prod,team=payments".
+// Tags without a value are kept with an empty value.
+func ParseTags(raw string) Tags {
+	tags := Tags{}
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		tags[key] = strings.TrimSpace(value)
+	}
+
+	return tags
+}
+
+// String returns the tags in their comma separated form, sorted by key
+func (t Tags) String() string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if t[key] == "" {
+			pairs = append(pairs, key)
+			continue
+		}
+		pairs = append(pairs, key+"="+t[key])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Resource represents a single resource reported by the SDM CLI
+type Resource struct {
+	ID               string
+	Name             string
+	Type             string
+	Hostname         string
+	Address          string
+	Port             int
+	Tags             Tags
+	Connected        bool
+	ConnectionStatus string
+	Message          string
+	WebURL           string
+}
+
+// rawResource mirrors a resource entry of `sdm status -j`
+type rawResource struct {
+	Address          string `json:"address,omitempty"`
+	Connected        bool   `json:"connected"`
+	ConnectionStatus string `json:"connection_status"`
+	Hostname         string `json:"hostname"`
+	ID               string `json:"id"`
+	Message          string `json:"message"`
+	Name             string `json:"name"`
+	Tags             string `json:"tags"`
+	Type             string `json:"type"`
+	WebURL           string `json:"web_url,omitempty"`
+}
+
+// parseResources converts the JSON output of `sdm status -j` into resources. It returns nil when
+// the output holds no list, e.g. empty or `null`, and an empty slice for `[]`: only the latter
+// tells that the account has no resources.
+func parseResources(output string) ([]Resource, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		log.Warn().Msg("Empty resource data received")
+		return nil, nil
+	}
+
+	var raw []rawResource
+	if err := json.Unmarshal([]byte(output), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJSONParsing, err)
+	}
+	if raw == nil {
+		log.Warn().Msg("No resource list received")
+		return nil, nil
+	}
+
+	resources := make([]Resource, 0, len(raw))
+	for _, r := range raw {
+		resources = append(resources, Resource{
+			ID:               r.ID,
+			Name:             r.Name,
+			Type:             r.Type,
+			Hostname:         r.Hostname,
+			Address:          r.Address,
+			Port:             parsePort(r.Address),
+			Tags:             ParseTags(r.Tags),
+			Connected:        r.Connected,
+			ConnectionStatus: r.ConnectionStatus,
+			Message:          r.Message,
+			WebURL:           r.WebURL,
+		})
+	}
+
+	log.Debug().Int("resource_count", len(resources)).Msg("Parsed resources")
+	return resources, nil
+}
+
+// parsePort extracts the local port from a "host:port" address, returning 0 when there is none
+func parsePort(address string) int {
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return 0
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 0
+	}
+
+	return port
+}
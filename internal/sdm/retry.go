@@ -0,0 +1,178 @@
+package sdm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidRetry indicates that a retry configuration could not be used
+var ErrInvalidRetry = errors.New("invalid retry configuration")
+
+// RetryConfig is the configuration form of a RetryPolicy. Zero values use the defaults.
+type RetryConfig struct {
+	MaxAttempts  int           `mapstructure:"maxAttempts"`
+	InitialDelay time.Duration `mapstructure:"initialDelay"`
+	MaxDelay     time.Duration `mapstructure:"maxDelay"`
+	Multiplier   float64       `mapstructure:"multiplier"`
+	Jitter       float64       `mapstructure:"jitter"`
+	RetryOn      []string      `mapstructure:"retryOn"`
+}
+
+// RetryPolicy decides whether and when a failed SDM command is run again
+type RetryPolicy struct {
+	MaxAttempts  int           // Total number of attempts, including the first one
+	InitialDelay time.Duration // Delay before the first retry
+	MaxDelay     time.Duration // Upper bound of the delay between attempts
+	Multiplier   float64       // Growth of the delay after every retry
+	Jitter       float64       // Fraction of the delay randomly added or removed, from 0 to 1
+	RetryOn      []SDMErrorCode
+
+	random func() float64
+}
+
+// DefaultRetryPolicy retries transient failures, e.g. while the listener restarts
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  3,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+	RetryOn:      []SDMErrorCode{ConnectionFailed, Timeout},
+}
+
+// errorCodes holds the error codes by name, for the configuration
+var errorCodes = map[string]SDMErrorCode{
+	Unauthorized.String():       Unauthorized,
+	InvalidCredentials.String(): InvalidCredentials,
+	ResourceNotFound.String():   ResourceNotFound,
+	ConnectionFailed.String():   ConnectionFailed,
+	PermissionDenied.String():   PermissionDenied,
+	Timeout.String():            Timeout,
+	Unknown.String():            Unknown,
+}
+
+// ParseErrorCode returns the error code with the given name, ignoring case
+func ParseErrorCode(name string) (SDMErrorCode, error) {
+	for codeName, code := range errorCodes {
+		if strings.EqualFold(codeName, strings.TrimSpace(name)) {
+			return code, nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown error code %q", name)
+}
+
+// NewRetryPolicy validates the configuration and fills the missing settings with the defaults
+func NewRetryPolicy(cfg RetryConfig) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+
+	if cfg.MaxAttempts < 0 {
+		return RetryPolicy{}, fmt.Errorf("%w: maxAttempts must be at least 1, got %d", ErrInvalidRetry, cfg.MaxAttempts)
+	}
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+
+	if cfg.InitialDelay < 0 || cfg.MaxDelay < 0 {
+		return RetryPolicy{}, fmt.Errorf("%w: delays can't be negative", ErrInvalidRetry)
+	}
+	if cfg.InitialDelay > 0 {
+		policy.InitialDelay = cfg.InitialDelay
+	}
+	if cfg.MaxDelay > 0 {
+		policy.MaxDelay = cfg.MaxDelay
+	}
+	if policy.MaxDelay < policy.InitialDelay {
+		return RetryPolicy{}, fmt.Errorf("%w: maxDelay %s is shorter than initialDelay %s", ErrInvalidRetry, policy.MaxDelay, policy.InitialDelay)
+	}
+
+	if cfg.Multiplier != 0 {
+		if cfg.Multiplier < 1 {
+			return RetryPolicy{}, fmt.Errorf("%w: multiplier must be at least 1, got %g", ErrInvalidRetry, cfg.Multiplier)
+		}
+		policy.Multiplier = cfg.Multiplier
+	}
+
+	if cfg.Jitter < 0 || cfg.Jitter > 1 {
+		return RetryPolicy{}, fmt.Errorf("%w: jitter must be between 0 and 1, got %g", ErrInvalidRetry, cfg.Jitter)
+	}
+	if cfg.Jitter > 0 {
+		policy.Jitter = cfg.Jitter
+	}
+
+	if len(cfg.RetryOn) > 0 {
+		policy.RetryOn = make([]SDMErrorCode, 0, len(cfg.RetryOn))
+		for _, name := range cfg.RetryOn {
+			code, err := ParseErrorCode(name)
+			if err != nil {
+				return RetryPolicy{}, fmt.Errorf("%w: retryOn: %v", ErrInvalidRetry, err)
+			}
+			// Authentication errors are handled by logging in again, not by retrying
+			if code == Unauthorized || code == InvalidCredentials {
+				return RetryPolicy{}, fmt.Errorf("%w: retryOn: %s can't be retried", ErrInvalidRetry, code)
+			}
+			policy.RetryOn = append(policy.RetryOn, code)
+		}
+	}
+
+	return policy, nil
+}
+
+// Retryable reports whether the error is an SDM error the policy retries
+func (r RetryPolicy) Retryable(err error) bool {
+	var sdmErr SDMError
+	return errors.As(err, &sdmErr) && slices.Contains(r.RetryOn, sdmErr.Code)
+}
+
+// Delay returns the wait before the given retry, starting at 1
+func (r RetryPolicy) Delay(retry int) time.Duration {
+	delay := float64(r.InitialDelay) * math.Pow(r.Multiplier, float64(retry-1))
+	delay = math.Min(delay, float64(r.MaxDelay))
+
+	if r.Jitter > 0 {
+		random := r.random
+		if random == nil {
+			random = rand.Float64
+		}
+		// Spread the delay over [1-jitter, 1+jitter] so concurrent commands don't retry together
+		delay *= 1 - r.Jitter + 2*r.Jitter*random()
+	}
+
+	return time.Duration(delay)
+}
+
+// Do runs the command until it succeeds, fails with an error the policy doesn't retry or
+// runs out of attempts. The wait between attempts ends early when the context is done.
+func (r RetryPolicy) Do(ctx context.Context, command func() error) error {
+	attempts := max(r.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := command()
+		if err == nil || attempt >= attempts || !r.Retryable(err) {
+			return err
+		}
+
+		delay := r.Delay(attempt)
+		log.Debug().
+			Err(err).
+			Int("attempt", attempt).
+			Int("maxAttempts", attempts).
+			Dur("delay", delay).
+			Msg("Retrying command")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"strings"
 	"time"
 
@@ -16,9 +15,15 @@ import (
 // DefaultTimeout is the default timeout for SDM operations
 const DefaultTimeout = 30 * time.Second
 
+// DefaultReadyInterval is how often WaitReadyWithContext polls the SDM client
+const DefaultReadyInterval = 500 * time.Millisecond
+
 // ErrJSONParsing indicates a failure in parsing JSON output
 var ErrJSONParsing = errors.New("failed to parse JSON output")
 
+// ErrNotReady indicates that the SDM client did not become ready in time
+var ErrNotReady = errors.New("sdm is not ready")
+
 // SdmReady represents the state of the SDM client
 type SdmReady struct {
 	Account         *string `json:"account"`
@@ -27,6 +32,17 @@ type SdmReady struct {
 	IsLinked        bool    `json:"is_linked"`
 }
 
+// Loaded reports whether the listener is running and has loaded its state
+func (r SdmReady) Loaded() bool {
+	return r.ListenerRunning && r.StateLoaded
+}
+
+// LoggedOut reports whether the listener is running without an account. Its state may then
+// only be loaded after logging in, so waiting for it would never end.
+func (r SdmReady) LoggedOut() bool {
+	return r.ListenerRunning && r.Account == nil
+}
+
 // SDMClient provides methods to interact with the SDM CLI
 type SDMClient struct {
 	CommandRunner *cmder.CommandRunner
@@ -67,6 +83,15 @@ func NewSDMClient(exe string, opts ...SDMClientOption) *SDMClient {
 	return client
 }
 
+// runner returns a fresh command runner per call, since CommandRunner keeps
+// per-command state and the client may be used from several goroutines
+func (s *SDMClient) runner() *cmder.CommandRunner {
+	return &cmder.CommandRunner{
+		Exe:         s.CommandRunner.Exe,
+		ErrorParser: s.CommandRunner.ErrorParser,
+	}
+}
+
 // ReadyWithContext checks if the SDM client is ready and returns the state using the provided context
 func (s *SDMClient) ReadyWithContext(ctx context.Context) (SdmReady, error) {
 	var output strings.Builder
@@ -74,7 +99,7 @@ func (s *SDMClient) ReadyWithContext(ctx context.Context) (SdmReady, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	err := s.CommandRunner.RunCommandWithContext(
+	err := s.runner().RunCommandWithContext(
 		ctxWithTimeout,
 		cmder.WithArgs("ready"),
 		cmder.WithOutput(&output),
@@ -99,13 +124,70 @@ func (s *SDMClient) Ready() (SdmReady, error) {
 	return s.ReadyWithContext(context.Background())
 }
 
+// WaitReadyWithContext polls the SDM client every interval until its state is loaded, it is
+// logged out or the context is done
+func (s *SDMClient) WaitReadyWithContext(ctx context.Context, interval time.Duration) (SdmReady, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// The ready command fails while the listener is still starting, keep polling
+		ready, err := s.ReadyWithContext(ctx)
+		if err == nil && (ready.Loaded() || ready.LoggedOut()) {
+			return ready, nil
+		}
+
+		log.Debug().
+			Err(err).
+			Bool("listenerRunning", ready.ListenerRunning).
+			Bool("stateLoaded", ready.StateLoaded).
+			Msg("Waiting for SDM to be ready")
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return ready, fmt.Errorf("%w: %w", ErrNotReady, err)
+			}
+			return ready, fmt.Errorf("%w: %w", ErrNotReady, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListenWithContext starts the SDM listener in the background using the provided context
+func (s *SDMClient) ListenWithContext(ctx context.Context) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var output strings.Builder
+
+	err := s.runner().RunCommandWithContext(
+		ctxWithTimeout,
+		cmder.WithArgs("listen", "--daemon"),
+		cmder.WithOutput(&output),
+		cmder.WithErrorParser(parseSdmError),
+	)
+	if err != nil {
+		log.Debug().Err(err).Str("output", output.String()).Msg("Listen failed")
+		return fmt.Errorf("listen command failed: %w", err)
+	}
+
+	log.Debug().Msg("Listener started")
+	return nil
+}
+
+// Listen starts the SDM listener in the background
+func (s *SDMClient) Listen() error {
+	return s.ListenWithContext(context.Background())
+}
+
 // LogoutWithContext logs out the user from the SDM client using the provided context
 func (s *SDMClient) LogoutWithContext(ctx context.Context) error {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	var output strings.Builder
-	err := s.CommandRunner.RunCommandWithContext(
+	err := s.runner().RunCommandWithContext(
 		ctxWithTimeout,
 		cmder.WithArgs("logout"),
 		cmder.WithOutput(&output),
@@ -133,7 +215,7 @@ func (s *SDMClient) LoginWithContext(ctx context.Context, email, password string
 	stdin := strings.NewReader(password + "\n")
 	var output strings.Builder
 
-	err := s.CommandRunner.RunCommandWithContext(
+	err := s.runner().RunCommandWithContext(
 		ctxWithTimeout,
 		cmder.WithArgs("login", "--email", email),
 		cmder.WithStdin(stdin),
@@ -158,27 +240,40 @@ func (s *SDMClient) Login(email, password string) error {
 	return s.LoginWithContext(context.Background(), email, password)
 }
 
-// StatusWithContext writes the status of the SDM client to the provided writer using the provided context
-func (s *SDMClient) StatusWithContext(ctx context.Context, output io.Writer) error {
+// StatusWithContext returns the resources reported by the SDM client using the provided context
+func (s *SDMClient) StatusWithContext(ctx context.Context) ([]Resource, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	err := s.CommandRunner.RunCommandWithContext(
+	// Keep stderr apart so warnings printed by the CLI don't corrupt the JSON
+	var stdout, stderr strings.Builder
+
+	err := s.runner().RunCommandWithContext(
 		ctxWithTimeout,
 		cmder.WithArgs("status", "-j"),
-		cmder.WithOutput(output),
+		cmder.WithStdout(&stdout),
+		cmder.WithStderr(&stderr),
 		cmder.WithErrorParser(parseSdmError),
 	)
 	if err != nil {
-		return fmt.Errorf("status command failed: %w", err)
+		return nil, fmt.Errorf("status command failed: %w", err)
 	}
 
-	return nil
+	resources, err := parseResources(stdout.String())
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("stderr", stderr.String()).
+			Msg("Failed to parse status output")
+		return nil, err
+	}
+
+	return resources, nil
 }
 
-// Status writes the status of the SDM client to the provided writer
-func (s *SDMClient) Status(output io.Writer) error {
-	return s.StatusWithContext(context.Background(), output)
+// Status returns the resources reported by the SDM client
+func (s *SDMClient) Status() ([]Resource, error) {
+	return s.StatusWithContext(context.Background())
 }
 
 // ConnectWithContext connects to the specified data source using the provided context
@@ -188,7 +283,7 @@ func (s *SDMClient) ConnectWithContext(ctx context.Context, dataSource string) e
 
 	var output strings.Builder
 
-	err := s.CommandRunner.RunCommandWithContext(
+	err := s.runner().RunCommandWithContext(
 		ctxWithTimeout,
 		cmder.WithArgs("connect", dataSource),
 		cmder.WithOutput(&output),
@@ -211,3 +306,64 @@ func (s *SDMClient) ConnectWithContext(ctx context.Context, dataSource string) e
 func (s *SDMClient) Connect(dataSource string) error {
 	return s.ConnectWithContext(context.Background(), dataSource)
 }
+
+// DisconnectWithContext disconnects from the specified data source using the provided context
+func (s *SDMClient) DisconnectWithContext(ctx context.Context, dataSource string) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var output strings.Builder
+
+	err := s.runner().RunCommandWithContext(
+		ctxWithTimeout,
+		cmder.WithArgs("disconnect", dataSource),
+		cmder.WithOutput(&output),
+		cmder.WithErrorParser(parseSdmError),
+	)
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("dataSource", dataSource).
+			Str("output", output.String()).
+			Msg("Disconnect failed")
+		return fmt.Errorf("disconnect command failed for '%s': %w", dataSource, err)
+	}
+
+	log.Debug().Str("dataSource", dataSource).Msg("Disconnect successful")
+	return nil
+}
+
+// Disconnect disconnects from the specified data source
+func (s *SDMClient) Disconnect(dataSource string) error {
+	return s.DisconnectWithContext(context.Background(), dataSource)
+}
+
+// DisconnectAllWithContext disconnects from all connected data sources using the provided context
+func (s *SDMClient) DisconnectAllWithContext(ctx context.Context) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var output strings.Builder
+
+	err := s.runner().RunCommandWithContext(
+		ctxWithTimeout,
+		cmder.WithArgs("disconnect", "--all"),
+		cmder.WithOutput(&output),
+		cmder.WithErrorParser(parseSdmError),
+	)
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("output", output.String()).
+			Msg("Disconnect all failed")
+		return fmt.Errorf("disconnect command failed for all data sources: %w", err)
+	}
+
+	log.Debug().Msg("Disconnect all successful")
+	return nil
+}
+
+// DisconnectAll disconnects from all connected data sources
+func (s *SDMClient) DisconnectAll() error {
+	return s.DisconnectAllWithContext(context.Background())
+}
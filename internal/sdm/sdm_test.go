@@ -1,11 +1,11 @@
 package sdm
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,7 +13,14 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const testSdmBehavior = "TEST_SDM_BEHAVIOR"
+const (
+	testSdmBehavior = "TEST_SDM_BEHAVIOR"
+	// testSdmAttempts names a file counting the runs of the flaky behavior
+	testSdmAttempts = "TEST_SDM_ATTEMPTS"
+)
+
+// flakyFailures is how many runs of the flaky behavior fail before one succeeds
+const flakyFailures = 2
 
 // Test behaviors
 type TestBehavior int
@@ -32,10 +39,23 @@ const (
 	cmdStatusSuccessBehavior
 	cmdStatusNotAuthenticatedBehavior
 	cmdStatusErrorBehavior
+	cmdStatusInvalidJSONBehavior
 	cmdConnectSuccessBehavior
 	cmdConnectNotAuthenticatedBehavior
 	cmdConnectResourceNotFoundBehavior
 	cmdConnectErrorBehavior
+	cmdDisconnectSuccessBehavior
+	cmdDisconnectNotAuthenticatedBehavior
+	cmdDisconnectResourceNotFoundBehavior
+	cmdDisconnectErrorBehavior
+	cmdConnectRefusedBehavior
+	cmdConnectHangBehavior
+	cmdConnectFlakyBehavior
+	cmdReadyListenerStoppedBehavior
+	cmdReadyLoadingBehavior
+	cmdListenSuccessBehavior
+	cmdListenErrorBehavior
+	cmdReadyLoggedOutBehavior
 )
 
 // String conversion for TestBehavior
@@ -54,10 +74,23 @@ func (tb TestBehavior) String() string {
 		"cmdStatusSuccessBehavior",
 		"cmdStatusNotAuthenticatedBehavior",
 		"cmdStatusErrorBehavior",
+		"cmdStatusInvalidJSONBehavior",
 		"cmdConnectSuccessBehavior",
 		"cmdConnectNotAuthenticatedBehavior",
 		"cmdConnectResourceNotFoundBehavior",
 		"cmdConnectErrorBehavior",
+		"cmdDisconnectSuccessBehavior",
+		"cmdDisconnectNotAuthenticatedBehavior",
+		"cmdDisconnectResourceNotFoundBehavior",
+		"cmdDisconnectErrorBehavior",
+		"cmdConnectRefusedBehavior",
+		"cmdConnectHangBehavior",
+		"cmdConnectFlakyBehavior",
+		"cmdReadyListenerStoppedBehavior",
+		"cmdReadyLoadingBehavior",
+		"cmdListenSuccessBehavior",
+		"cmdListenErrorBehavior",
+		"cmdReadyLoggedOutBehavior",
 	}
 
 	if int(tb) < 0 || int(tb) >= len(behaviors) {
@@ -66,6 +99,12 @@ func (tb TestBehavior) String() string {
 	return behaviors[tb]
 }
 
+// statusOutput is a trimmed down `sdm status -j` response
+const statusOutput = `[
+	{"id":"rs-1","name":"payments-db","type":"postgres","hostname":"db.internal","address":"localhost:10001","connected":true,"connection_status":"connected","tags":"env=prod, team=payments"},
+	{"id":"rs-2","name":"grafana","type":"httpNoAuth","hostname":"grafana.internal","connected":false,"connection_status":"not connected","message":"http://grafana.example.com","web_url":"http://grafana.example.com","tags":""}
+]`
+
 // TestMain handles special behavior when running as a subprocess
 func TestMain(m *testing.M) {
 	behavior := os.Getenv(testSdmBehavior)
@@ -75,28 +114,64 @@ func TestMain(m *testing.M) {
 		os.Exit(m.Run())
 	}
 
+	switch behavior {
+	case cmdConnectHangBehavior.String():
+		// Outlive the client timeout so the command gets killed
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
+	case cmdConnectFlakyBehavior.String():
+		// Fail like a restarting listener until enough attempts were made
+		path := os.Getenv(testSdmAttempts)
+		data, _ := os.ReadFile(path)
+		attempts := len(data) + 1
+		os.WriteFile(path, append(data, '.'), 0o600)
+		if attempts <= flakyFailures {
+			fmt.Println("Connection refused")
+			os.Exit(1)
+		}
+		fmt.Println("connected")
+		os.Exit(0)
+	case cmdReadyLoadingBehavior.String():
+		// Report the state as loading until enough polls were made
+		path := os.Getenv(testSdmAttempts)
+		data, _ := os.ReadFile(path)
+		os.WriteFile(path, append(data, '.'), 0o600)
+		fmt.Printf(`{"account":"some.account@mail.com","listener_running":true,"state_loaded":%t,"is_linked":true}`+"\n", len(data) >= flakyFailures)
+		os.Exit(0)
+	}
+
 	// Map behavior to command output and exit code
 	outputMap := map[string]struct {
 		output   string
 		exitCode int
 	}{
-		cmdReadySuccessBehavior.String():            {`{"account":"some.account@mail.com","listener_running":true,"state_loaded":true,"is_linked":true}`, 0},
-		cmdReadyNoAccountBehavior.String():          {`{"listener_running":true,"state_loaded":true,"is_linked":true}`, 0},
-		cmdReadyErrorBehavior.String():              {``, 1},
-		cmdLoginSuccessBehavior.String():            {`logged in`, 0},
-		cmdLoginErrorNoAccountBehavior.String():     {`This email doesn't have a strongDM account.`, 1},
-		cmdLoginErrorUnknownBehavior.String():       {`cannot ask for password`, 1},
-		cmdLoginInvalidCredentialsBehavior.String(): {`access denied\n`, 1},
-		cmdLogoutSuccessBehavior.String():           {`logged out`, 0},
-		cmdLogoutNotAuthenticatedBehavior.String():  {`You are not authenticated. Please login again.`, 9},
-		cmdLogoutErrorBehavior.String():             {``, 1},
-		cmdStatusSuccessBehavior.String():           {`random output`, 0},
-		cmdStatusNotAuthenticatedBehavior.String():  {`You are not authenticated. Please login again.`, 9},
-		cmdStatusErrorBehavior.String():             {``, 1},
-		cmdConnectSuccessBehavior.String():          {`random output`, 0},
-		cmdConnectErrorBehavior.String():            {``, 1},
-		cmdConnectNotAuthenticatedBehavior.String(): {`You are not authenticated. Please login again.`, 9},
-		cmdConnectResourceNotFoundBehavior.String(): {`Cannot find datasource named ''`, 1},
+		cmdReadySuccessBehavior.String():               {`{"account":"some.account@mail.com","listener_running":true,"state_loaded":true,"is_linked":true}`, 0},
+		cmdReadyNoAccountBehavior.String():             {`{"listener_running":true,"state_loaded":true,"is_linked":true}`, 0},
+		cmdReadyErrorBehavior.String():                 {``, 1},
+		cmdLoginSuccessBehavior.String():               {`logged in`, 0},
+		cmdLoginErrorNoAccountBehavior.String():        {`This email doesn't have a strongDM account.`, 1},
+		cmdLoginErrorUnknownBehavior.String():          {`cannot ask for password`, 1},
+		cmdLoginInvalidCredentialsBehavior.String():    {`access denied\n`, 1},
+		cmdLogoutSuccessBehavior.String():              {`logged out`, 0},
+		cmdLogoutNotAuthenticatedBehavior.String():     {`You are not authenticated. Please login again.`, 9},
+		cmdLogoutErrorBehavior.String():                {``, 1},
+		cmdStatusSuccessBehavior.String():              {statusOutput, 0},
+		cmdStatusInvalidJSONBehavior.String():          {`random output`, 0},
+		cmdStatusNotAuthenticatedBehavior.String():     {`You are not authenticated. Please login again.`, 9},
+		cmdStatusErrorBehavior.String():                {``, 1},
+		cmdConnectSuccessBehavior.String():             {`random output`, 0},
+		cmdConnectErrorBehavior.String():               {``, 1},
+		cmdConnectNotAuthenticatedBehavior.String():    {`You are not authenticated. Please login again.`, 9},
+		cmdConnectResourceNotFoundBehavior.String():    {`Cannot find datasource named ''`, 1},
+		cmdDisconnectSuccessBehavior.String():          {`random output`, 0},
+		cmdDisconnectErrorBehavior.String():            {``, 1},
+		cmdDisconnectNotAuthenticatedBehavior.String(): {`You are not authenticated. Please login again.`, 9},
+		cmdDisconnectResourceNotFoundBehavior.String(): {`Cannot find datasource named ''`, 1},
+		cmdConnectRefusedBehavior.String():             {`Connection refused`, 1},
+		cmdReadyListenerStoppedBehavior.String():       {`{"listener_running":false,"state_loaded":false,"is_linked":true}`, 0},
+		cmdListenSuccessBehavior.String():              {`listener started`, 0},
+		cmdListenErrorBehavior.String():                {`Permission denied`, 1},
+		cmdReadyLoggedOutBehavior.String():             {`{"listener_running":true,"state_loaded":false,"is_linked":true}`, 0},
 	}
 
 	// Find expected behavior
@@ -206,6 +281,90 @@ func TestSDMClient_Ready(t *testing.T) {
 	}
 }
 
+func TestSDMClient_WaitReady(t *testing.T) {
+	tests := []sdmTestCase{
+		{
+			name:        "AlreadyLoaded",
+			behavior:    cmdReadySuccessBehavior,
+			shouldError: false,
+		},
+		{
+			name:        "LoadsAfterPolling",
+			behavior:    cmdReadyLoadingBehavior,
+			shouldError: false,
+		},
+		{
+			name:        "LoggedOut",
+			behavior:    cmdReadyLoggedOutBehavior,
+			shouldError: false,
+		},
+		{
+			name:           "ListenerStopped",
+			behavior:       cmdReadyListenerStoppedBehavior,
+			expectedErrMsg: ErrNotReady.Error(),
+			shouldError:    true,
+		},
+		{
+			name:           "ReadyFails",
+			behavior:       cmdReadyErrorBehavior,
+			expectedErrMsg: "ready command failed",
+			shouldError:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(testSdmAttempts, filepath.Join(t.TempDir(), "attempts"))
+
+			runWithContext(t, tc, func(ctx context.Context) error {
+				client := createTestSDMClient(t)
+
+				ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+				defer cancel()
+
+				ready, err := client.WaitReadyWithContext(ctx, 10*time.Millisecond)
+				switch {
+				case err != nil:
+					assert.ErrorIs(t, err, ErrNotReady)
+				case tc.behavior == cmdReadyLoggedOutBehavior:
+					assert.True(t, ready.LoggedOut(), "a logged out listener should not be waited for")
+					assert.False(t, ready.Loaded())
+				default:
+					assert.True(t, ready.Loaded())
+				}
+
+				return err
+			})
+		})
+	}
+}
+
+func TestSDMClient_Listen(t *testing.T) {
+	tests := []sdmTestCase{
+		{
+			name:        "SuccessfulListen",
+			behavior:    cmdListenSuccessBehavior,
+			shouldError: false,
+		},
+		{
+			name:            "ErrorPermissionDenied",
+			behavior:        cmdListenErrorBehavior,
+			expectedErrMsg:  "Permission denied",
+			expectedErrCode: PermissionDenied,
+			shouldError:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runWithContext(t, tc, func(ctx context.Context) error {
+				client := createTestSDMClient(t)
+				return client.ListenWithContext(ctx)
+			})
+		})
+	}
+}
+
 func TestSDMClient_Login(t *testing.T) {
 	tests := []sdmTestCase{
 		{
@@ -288,6 +447,12 @@ func TestSDMClient_Status(t *testing.T) {
 			behavior:    cmdStatusErrorBehavior,
 			shouldError: true,
 		},
+		{
+			name:           "InvalidJSON",
+			behavior:       cmdStatusInvalidJSONBehavior,
+			expectedErrMsg: ErrJSONParsing.Error(),
+			shouldError:    true,
+		},
 		{
 			name:            "NotAuthenticated",
 			behavior:        cmdStatusNotAuthenticatedBehavior,
@@ -301,12 +466,25 @@ func TestSDMClient_Status(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			runWithContext(t, tc, func(ctx context.Context) error {
 				client := createTestSDMClient(t)
-				buf := bytes.NewBuffer(nil)
-				err := client.StatusWithContext(ctx, buf)
-
-				// Check output for successful status
-				if err == nil && tc.behavior == cmdStatusSuccessBehavior {
-					assert.Contains(t, buf.String(), "random output")
+				resources, err := client.StatusWithContext(ctx)
+
+				switch tc.behavior {
+				case cmdStatusSuccessBehavior:
+					require.Len(t, resources, 2)
+
+					assert.Equal(t, "rs-1", resources[0].ID)
+					assert.Equal(t, "payments-db", resources[0].Name)
+					assert.Equal(t, "db.internal", resources[0].Hostname)
+					assert.Equal(t, 10001, resources[0].Port)
+					assert.Equal(t, Tags{"env": "prod", "team": "payments"}, resources[0].Tags)
+					assert.True(t, resources[0].Connected)
+					assert.Equal(t, "connected", resources[0].ConnectionStatus)
+
+					assert.Equal(t, 0, resources[1].Port)
+					assert.Empty(t, resources[1].Tags)
+					assert.Equal(t, "http://grafana.example.com", resources[1].WebURL)
+				case cmdStatusInvalidJSONBehavior:
+					assert.ErrorIs(t, err, ErrJSONParsing)
 				}
 
 				return err
@@ -315,6 +493,52 @@ func TestSDMClient_Status(t *testing.T) {
 	}
 }
 
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected Tags
+	}{
+		{raw: "", expected: Tags{}},
+		{raw: "env=prod", expected: Tags{"env": "prod"}},
+		{raw: "env=prod,team=payments", expected: Tags{"env": "prod", "team": "payments"}},
+		{raw: " env = prod , readonly ", expected: Tags{"env": "prod", "readonly": ""}},
+		{raw: "url=http://a=b,,=orphan", expected: Tags{"url": "http://a=b"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.raw, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseTags(tc.raw))
+		})
+	}
+}
+
+func TestParseResources_Empty(t *testing.T) {
+	// Only `[]` should look like an account without resources
+	tests := []struct {
+		output   string
+		complete bool
+	}{
+		{"", false},
+		{"  \n", false},
+		{"null", false},
+		{"[]", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%q", tc.output), func(t *testing.T) {
+			resources, err := parseResources(tc.output)
+			require.NoError(t, err)
+			assert.Empty(t, resources)
+			assert.Equal(t, tc.complete, resources != nil)
+		})
+	}
+}
+
+func TestTags_String(t *testing.T) {
+	assert.Equal(t, "", Tags{}.String())
+	assert.Equal(t, "env=prod,readonly,team=payments", Tags{"team": "payments", "env": "prod", "readonly": ""}.String())
+}
+
 func TestSDMClient_Connect(t *testing.T) {
 	tests := []sdmTestCase{
 		{
@@ -342,6 +566,20 @@ func TestSDMClient_Connect(t *testing.T) {
 			expectedErrCode: ResourceNotFound,
 			shouldError:     true,
 		},
+		{
+			name:            "ConnectionRefused",
+			behavior:        cmdConnectRefusedBehavior,
+			expectedErrMsg:  "Connection refused",
+			expectedErrCode: ConnectionFailed,
+			shouldError:     true,
+		},
+		{
+			name:            "Timeout",
+			behavior:        cmdConnectHangBehavior,
+			expectedErrMsg:  "command timed out",
+			expectedErrCode: Timeout,
+			shouldError:     true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -353,3 +591,217 @@ func TestSDMClient_Connect(t *testing.T) {
 		})
 	}
 }
+
+func TestSDMClient_Disconnect(t *testing.T) {
+	tests := []sdmTestCase{
+		{
+			name:        "SuccessfulDisconnect",
+			behavior:    cmdDisconnectSuccessBehavior,
+			shouldError: false,
+		},
+		{
+			name:            "ErrorUnknown",
+			behavior:        cmdDisconnectErrorBehavior,
+			expectedErrCode: Unknown,
+			shouldError:     true,
+		},
+		{
+			name:            "NotAuthenticated",
+			behavior:        cmdDisconnectNotAuthenticatedBehavior,
+			expectedErrMsg:  "You are not authenticated",
+			expectedErrCode: Unauthorized,
+			shouldError:     true,
+		},
+		{
+			name:            "ResourceNameMissing",
+			behavior:        cmdDisconnectResourceNotFoundBehavior,
+			expectedErrMsg:  "Cannot find datasource",
+			expectedErrCode: ResourceNotFound,
+			shouldError:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runWithContext(t, tc, func(ctx context.Context) error {
+				client := createTestSDMClient(t)
+				return client.DisconnectWithContext(ctx, "resource_name")
+			})
+		})
+	}
+}
+
+func TestSDMClient_DisconnectAll(t *testing.T) {
+	tests := []sdmTestCase{
+		{
+			name:        "SuccessfulDisconnectAll",
+			behavior:    cmdDisconnectSuccessBehavior,
+			shouldError: false,
+		},
+		{
+			name:            "ErrorUnknown",
+			behavior:        cmdDisconnectErrorBehavior,
+			expectedErrCode: Unknown,
+			shouldError:     true,
+		},
+		{
+			name:            "NotAuthenticated",
+			behavior:        cmdDisconnectNotAuthenticatedBehavior,
+			expectedErrMsg:  "You are not authenticated",
+			expectedErrCode: Unauthorized,
+			shouldError:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runWithContext(t, tc, func(ctx context.Context) error {
+				client := createTestSDMClient(t)
+				return client.DisconnectAllWithContext(ctx)
+			})
+		})
+	}
+}
+
+// testRetryPolicy is a retry policy without waits between attempts
+func testRetryPolicy(t *testing.T, cfg RetryConfig) RetryPolicy {
+	if cfg.InitialDelay == 0 {
+		cfg.InitialDelay = time.Millisecond
+		cfg.MaxDelay = time.Millisecond
+	}
+	policy, err := NewRetryPolicy(cfg)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	tests := []struct {
+		name             string
+		behavior         TestBehavior
+		config           RetryConfig
+		expectedErrCode  SDMErrorCode
+		expectedAttempts int
+		shouldError      bool
+	}{
+		{
+			name:             "RecoversAfterTransientFailures",
+			behavior:         cmdConnectFlakyBehavior,
+			expectedAttempts: flakyFailures + 1,
+		},
+		{
+			name:             "GivesUpAfterMaxAttempts",
+			behavior:         cmdConnectFlakyBehavior,
+			config:           RetryConfig{MaxAttempts: flakyFailures},
+			expectedErrCode:  ConnectionFailed,
+			expectedAttempts: flakyFailures,
+			shouldError:      true,
+		},
+		{
+			name:             "SkipsCodesNotRetried",
+			behavior:         cmdConnectFlakyBehavior,
+			config:           RetryConfig{RetryOn: []string{"timeout"}},
+			expectedErrCode:  ConnectionFailed,
+			expectedAttempts: 1,
+			shouldError:      true,
+		},
+		{
+			name:             "NeverRetriesResourceNotFound",
+			behavior:         cmdConnectResourceNotFoundBehavior,
+			expectedErrCode:  ResourceNotFound,
+			expectedAttempts: 1,
+			shouldError:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(testSdmBehavior, tc.behavior.String())
+			t.Setenv(testSdmAttempts, filepath.Join(t.TempDir(), "attempts"))
+
+			client := createTestSDMClient(t)
+			policy := testRetryPolicy(t, tc.config)
+
+			attempts := 0
+			err := policy.Do(context.Background(), func() error {
+				attempts++
+				return client.Connect("resource_name")
+			})
+
+			if tc.shouldError {
+				var sdmErr SDMError
+				require.ErrorAs(t, err, &sdmErr)
+				assert.Equal(t, tc.expectedErrCode, sdmErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestRetryPolicy_DoCanceled(t *testing.T) {
+	t.Setenv(testSdmBehavior, cmdConnectRefusedBehavior.String())
+
+	client := createTestSDMClient(t)
+	policy := testRetryPolicy(t, RetryConfig{MaxAttempts: 5, InitialDelay: time.Minute, MaxDelay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	runs := 0
+	start := time.Now()
+	err := policy.Do(ctx, func() error {
+		runs++
+		return client.ConnectWithContext(ctx, "resource_name")
+	})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, runs, "no attempt should be made after the context is done")
+	assert.Less(t, time.Since(start), 5*time.Second, "the backoff should stop waiting when the context is done")
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := NewRetryPolicy(RetryConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetryPolicy.MaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, DefaultRetryPolicy.RetryOn, policy.RetryOn)
+
+	policy, err = NewRetryPolicy(RetryConfig{MaxAttempts: 5, RetryOn: []string{"connectionFailed", "Unknown"}})
+	require.NoError(t, err)
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, []SDMErrorCode{ConnectionFailed, Unknown}, policy.RetryOn)
+
+	invalid := []RetryConfig{
+		{MaxAttempts: -1},
+		{InitialDelay: -time.Second},
+		{InitialDelay: 10 * time.Second, MaxDelay: time.Second},
+		{Multiplier: 0.5},
+		{Jitter: 1.5},
+		{RetryOn: []string{"bogus"}},
+		{RetryOn: []string{"Unauthorized"}},
+	}
+	for _, cfg := range invalid {
+		_, err := NewRetryPolicy(cfg)
+		assert.ErrorIs(t, err, ErrInvalidRetry, "%+v", cfg)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   2,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Delay(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Delay(3))
+	assert.Equal(t, time.Second, policy.Delay(5), "the delay is capped by MaxDelay")
+
+	policy.Jitter = 0.5
+	policy.random = func() float64 { return 0 }
+	assert.Equal(t, 50*time.Millisecond, policy.Delay(1))
+	policy.random = func() float64 { return 1 }
+	assert.Equal(t, 150*time.Millisecond, policy.Delay(1))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"maps"
 )
 
 type DataSource struct {
@@ -11,9 +12,58 @@ type DataSource struct {
 	Status  string
 	Address string
 	Type    string
-	Tags    string
+	Tags    map[string]string
 	WebURL  string
 	LRU     int64 // Unix timestamp to sort on
+
+	UseCount   int     // Number of times the datasource was used
+	RecentUses []int64 // Unix timestamps of the most recent uses, oldest first
+	Pinned     bool    // Pinned datasources are always listed first
+	Alias      string  // User-defined short name
+}
+
+// maxRecentUses caps the usage history kept per datasource
+const maxRecentUses = 10
+
+// recordUse registers a use at the given Unix timestamp
+func (ds *DataSource) recordUse(at int64) {
+	ds.LRU = at
+	ds.UseCount++
+	ds.RecentUses = append(ds.RecentUses, at)
+	if len(ds.RecentUses) > maxRecentUses {
+		ds.RecentUses = ds.RecentUses[len(ds.RecentUses)-maxRecentUses:]
+	}
+}
+
+// preserveLocalState copies the usage history, pin and alias from a previously stored datasource
+func (ds *DataSource) preserveLocalState(existing DataSource) {
+	ds.LRU = existing.LRU
+	ds.UseCount = existing.UseCount
+	ds.RecentUses = existing.RecentUses
+	ds.Pinned = existing.Pinned
+	ds.Alias = existing.Alias
+}
+
+// changedFrom reports whether the datasource differs from the stored one in what StrongDM
+// reports about it. The connection status is left out as it changes with every connect.
+func (ds DataSource) changedFrom(existing DataSource) bool {
+	return ds.Address != existing.Address ||
+		ds.Type != existing.Type ||
+		ds.WebURL != existing.WebURL ||
+		!maps.Equal(ds.Tags, existing.Tags)
+}
+
+// SyncResult lists the names of the datasources added, removed and changed by a sync
+type SyncResult struct {
+	Total   int // Number of datasources listed by StrongDM
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether the sync changed nothing
+func (r SyncResult) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
 }
 
 // Encode serializes the DataSource into a byte slice.
@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -15,7 +19,9 @@ import (
 // Database constants
 const (
 	datasourceBucketPrefix = "datasource"
-	currentDBVersion       = 2 // increment this whenever the database schema changes
+	metaBucketPrefix       = "meta"
+	lastSyncedAtKey        = "lastSyncedAt"
+	currentDBVersion       = 3 // increment this whenever the database schema changes
 	retentionPeriod        = 2
 	defaultTimeout         = 5 * time.Second
 )
@@ -25,6 +31,7 @@ var (
 	ErrBucketNotFound     = errors.New("bucket not found")
 	ErrDataSourceNotFound = errors.New("datasource not found")
 	ErrDatabaseClosed     = errors.New("database is closed")
+	ErrAliasInUse         = errors.New("alias already in use")
 )
 
 // Storage manages persistence of data sources using BoltDB
@@ -32,6 +39,9 @@ type Storage struct {
 	*bolt.DB
 	account string
 	timeout time.Duration
+	path    string
+	shared  bool
+	mu      sync.Mutex // serializes the transactions of a shared database
 }
 
 // StorageOption is a function option for configuring the Storage
@@ -44,6 +54,15 @@ func WithTimeout(timeout time.Duration) StorageOption {
 	}
 }
 
+// WithShared only opens the database for the duration of each transaction, read-only for reads.
+// BoltDB locks the file while it is open, this lets another process, e.g. the daemon, write it
+// while this one waits on a menu.
+func WithShared() StorageOption {
+	return func(s *Storage) {
+		s.shared = true
+	}
+}
+
 // NewStorage initializes and returns a new Storage instance
 func NewStorage(account string, path string, opts ...StorageOption) (*Storage, error) {
 	if account == "" {
@@ -67,6 +86,7 @@ func NewStorage(account string, path string, opts ...StorageOption) (*Storage, e
 		DB:      db,
 		account: account,
 		timeout: defaultTimeout,
+		path:    dbPath,
 	}
 
 	// Apply options
@@ -74,6 +94,10 @@ func NewStorage(account string, path string, opts ...StorageOption) (*Storage, e
 		opt(storage)
 	}
 
+	// Initialize with the database opened above, a shared one is only reopened afterwards
+	shared := storage.shared
+	storage.shared = false
+
 	// Initialize bucket
 	if err := storage.ensureBucketExists(); err != nil {
 		// Close DB if initialization fails
@@ -86,11 +110,58 @@ func NewStorage(account string, path string, opts ...StorageOption) (*Storage, e
 		log.Warn().Err(err).Msg("Failed to remove old buckets during initialization")
 	}
 
+	// A shared database is opened again by every transaction
+	if shared {
+		storage.shared = true
+		storage.DB = nil
+		if err := db.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close database: %w", err)
+		}
+	}
+
 	return storage, nil
 }
 
+// View runs fn in a read-only transaction
+func (s *Storage) View(fn func(*bolt.Tx) error) error {
+	if !s.shared {
+		return s.DB.View(fn)
+	}
+	return s.transaction(true, func(db *bolt.DB) error {
+		return db.View(fn)
+	})
+}
+
+// Update runs fn in a read-write transaction
+func (s *Storage) Update(fn func(*bolt.Tx) error) error {
+	if !s.shared {
+		return s.DB.Update(fn)
+	}
+	return s.transaction(false, func(db *bolt.DB) error {
+		return db.Update(fn)
+	})
+}
+
+// transaction opens the shared database for the time of run
+func (s *Storage) transaction(readOnly bool, run func(*bolt.DB) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: s.timeout, ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	return run(db)
+}
+
 // Close closes the database connection
 func (s *Storage) Close() error {
+	if s.shared {
+		return nil
+	}
+
 	if s.DB == nil {
 		return ErrDatabaseClosed
 	}
@@ -105,24 +176,90 @@ func (s *Storage) ensureBucketExists() error {
 	log.Debug().Str("bucket", string(bucketKey)).Msg("Ensuring bucket exists")
 
 	return s.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucketKey)
-		if err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
+		if tx.Bucket(bucketKey) == nil {
+			bucket, err := tx.CreateBucket(bucketKey)
+			if err != nil {
+				return fmt.Errorf("failed to create bucket: %w", err)
+			}
+			if err := migrateFromV2(tx, s.account, bucket); err != nil {
+				log.Warn().Err(err).Msg("Failed to migrate datasources from the previous version")
+			}
+		}
+		if _, err := tx.CreateBucketIfNotExists(buildMetaBucketKey(s.account, currentDBVersion)); err != nil {
+			return fmt.Errorf("failed to create meta bucket: %w", err)
 		}
 		return nil
 	})
 }
 
+// v2DataSource holds the fields of a version 2 datasource that can be decoded into the current
+// version, its tags were stored as a single string
+type v2DataSource struct {
+	Name    string
+	Status  string
+	Address string
+	Type    string
+	WebURL  string
+	LRU     int64
+}
+
+// migrateFromV2 copies the datasources of the version 2 bucket, if any, into the new bucket so
+// that their usage history survives the upgrade. Their tags are filled in by the next sync.
+func migrateFromV2(tx *bolt.Tx, account string, bucket *bolt.Bucket) error {
+	previous := tx.Bucket(buildBucketKey(account, 2))
+	if previous == nil {
+		return nil
+	}
+
+	migrated := 0
+	err := previous.ForEach(func(k, v []byte) error {
+		var legacy v2DataSource
+		if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&legacy); err != nil {
+			log.Warn().Err(err).Str("name", string(k)).Msg("Failed to decode version 2 datasource")
+			return nil
+		}
+
+		encoded, err := DataSource{
+			Name:    legacy.Name,
+			Status:  legacy.Status,
+			Address: legacy.Address,
+			Type:    legacy.Type,
+			WebURL:  legacy.WebURL,
+			LRU:     legacy.LRU,
+		}.Encode()
+		if err != nil {
+			return err
+		}
+
+		migrated++
+		return bucket.Put(k, encoded)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Int("count", migrated).Msg("Migrated datasources from version 2")
+	return nil
+}
+
 // buildBucketKey constructs a bucket key
 func buildBucketKey(account string, version int) []byte {
 	return []byte(fmt.Sprintf("%s:%s:v%d", account, datasourceBucketPrefix, version))
 }
 
-// StoreServers stores the provided datasources
-func (s *Storage) StoreServers(datasources []DataSource) error {
-	if len(datasources) == 0 {
+// buildMetaBucketKey constructs the key of the bucket holding the account's cache metadata
+func buildMetaBucketKey(account string, version int) []byte {
+	return []byte(fmt.Sprintf("%s:%s:v%d", account, metaBucketPrefix, version))
+}
+
+// StoreServers stores the provided datasources, keeping the usage history, pin and alias of the
+// ones that were already stored, and reports what changed. When complete is set, the datasources
+// are everything the account has access to and the stored ones missing from them are removed,
+// even when the list is empty.
+func (s *Storage) StoreServers(datasources []DataSource, complete bool) (SyncResult, error) {
+	if len(datasources) == 0 && !complete {
 		log.Debug().Msg("No datasources to store")
-		return nil
+		return SyncResult{}, nil
 	}
 
 	bucketKey := buildBucketKey(s.account, currentDBVersion)
@@ -131,17 +268,25 @@ func (s *Storage) StoreServers(datasources []DataSource) error {
 		Str("bucket", string(bucketKey)).
 		Msg("Storing datasources")
 
-	return s.Update(func(tx *bolt.Tx) error {
+	var result SyncResult
+	err := s.Update(func(tx *bolt.Tx) error {
+		result = SyncResult{Total: len(datasources)}
+
 		bucket := tx.Bucket(bucketKey)
 		if bucket == nil {
 			return ErrBucketNotFound
 		}
 
+		synced := make(map[string]bool, len(datasources))
 		successCount := 0
 		for _, ds := range datasources {
-			// Preserve existing LRU value if present
+			synced[ds.Name] = true
+
+			// Preserve existing usage history, pin and alias if present
 			existingData := bucket.Get(ds.Key())
-			if existingData != nil {
+			if existingData == nil {
+				result.Added = append(result.Added, ds.Name)
+			} else {
 				var existingDS DataSource
 				if err := existingDS.Decode(existingData); err != nil {
 					log.Warn().
@@ -149,7 +294,10 @@ func (s *Storage) StoreServers(datasources []DataSource) error {
 						Str("name", ds.Name).
 						Msg("Failed to decode existing datasource")
 				} else {
-					ds.LRU = existingDS.LRU
+					ds.preserveLocalState(existingDS)
+					if ds.changedFrom(existingDS) {
+						result.Changed = append(result.Changed, ds.Name)
+					}
 				}
 			}
 
@@ -174,13 +322,81 @@ func (s *Storage) StoreServers(datasources []DataSource) error {
 			successCount++
 		}
 
+		// Remove the datasources that are no longer listed, e.g. revoked or deleted in StrongDM
+		if complete {
+			err := bucket.ForEach(func(k, _ []byte) error {
+				if !synced[string(k)] {
+					result.Removed = append(result.Removed, string(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to enumerate datasources: %w", err)
+			}
+
+			for _, name := range result.Removed {
+				if err := bucket.Delete([]byte(name)); err != nil {
+					return fmt.Errorf("failed to remove datasource %s: %w", name, err)
+				}
+			}
+		}
+
 		log.Debug().
 			Int("total", len(datasources)).
 			Int("success", successCount).
+			Int("added", len(result.Added)).
+			Int("removed", len(result.Removed)).
+			Int("changed", len(result.Changed)).
 			Msg("Stored datasources")
 
+		return setLastSyncedAt(tx, s.account, time.Now())
+	})
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	slices.Sort(result.Added)
+	slices.Sort(result.Changed)
+	return result, nil
+}
+
+// setLastSyncedAt records when the datasources of the account were last stored
+func setLastSyncedAt(tx *bolt.Tx, account string, at time.Time) error {
+	bucket := tx.Bucket(buildMetaBucketKey(account, currentDBVersion))
+	if bucket == nil {
+		return ErrBucketNotFound
+	}
+
+	value, err := at.MarshalText()
+	if err != nil {
+		return fmt.Errorf("failed to encode sync time: %w", err)
+	}
+
+	return bucket.Put([]byte(lastSyncedAtKey), value)
+}
+
+// LastSyncedAt returns when the datasources were last stored, the zero time if they never were
+func (s *Storage) LastSyncedAt() (time.Time, error) {
+	var at time.Time
+
+	err := s.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(buildMetaBucketKey(s.account, currentDBVersion))
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+
+		value := bucket.Get([]byte(lastSyncedAtKey))
+		if value == nil {
+			return nil
+		}
+
+		if err := at.UnmarshalText(value); err != nil {
+			return fmt.Errorf("failed to decode sync time: %w", err)
+		}
 		return nil
 	})
+
+	return at, err
 }
 
 // RetrieveDatasources retrieves all datasources
@@ -264,20 +480,110 @@ func (s *Storage) GetDatasource(name string) (DataSource, error) {
 	return datasource, nil
 }
 
-// UpdateLastUsed updates the last used timestamp of a datasource
+// UpdateLastUsed updates the last used timestamp and usage history of a datasource
 func (s *Storage) UpdateLastUsed(ds DataSource) error {
 	if ds.Name == "" {
 		return fmt.Errorf("datasource name cannot be empty")
 	}
 
-	// Update timestamp
-	ds.LRU = time.Now().Unix()
+	bucketKey := buildBucketKey(s.account, currentDBVersion)
+
+	return s.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketKey)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+
+		// Start from the stored history so concurrent updates aren't lost
+		if existingData := bucket.Get(ds.Key()); existingData != nil {
+			var existingDS DataSource
+			if err := existingDS.Decode(existingData); err != nil {
+				log.Warn().
+					Err(err).
+					Str("name", ds.Name).
+					Msg("Failed to decode existing datasource")
+			} else {
+				ds.preserveLocalState(existingDS)
+			}
+		}
+
+		ds.recordUse(time.Now().Unix())
+
+		log.Debug().
+			Str("name", ds.Name).
+			Int64("timestamp", ds.LRU).
+			Int("use_count", ds.UseCount).
+			Msg("Updating last used timestamp")
+
+		// Encode and store
+		encodedData, err := ds.Encode()
+		if err != nil {
+			return fmt.Errorf("failed to encode datasource: %w", err)
+		}
+
+		if err := bucket.Put(ds.Key(), encodedData); err != nil {
+			return fmt.Errorf("failed to store datasource: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// SetPinned pins or unpins a datasource
+func (s *Storage) SetPinned(name string, pinned bool) error {
+	if name == "" {
+		return fmt.Errorf("datasource name cannot be empty")
+	}
+
+	bucketKey := buildBucketKey(s.account, currentDBVersion)
+	log.Debug().
+		Str("name", name).
+		Bool("pinned", pinned).
+		Msg("Updating datasource pin")
+
+	return s.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketKey)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+
+		value := bucket.Get([]byte(name))
+		if value == nil {
+			return ErrDataSourceNotFound
+		}
+
+		var ds DataSource
+		if err := ds.Decode(value); err != nil {
+			return fmt.Errorf("failed to decode datasource: %w", err)
+		}
+
+		ds.Pinned = pinned
+
+		encodedData, err := ds.Encode()
+		if err != nil {
+			return fmt.Errorf("failed to encode datasource: %w", err)
+		}
+
+		if err := bucket.Put(ds.Key(), encodedData); err != nil {
+			return fmt.Errorf("failed to store datasource: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// SetAlias sets the alias of a datasource, an empty alias removes it.
+// Aliases must not clash with another datasource's name or alias.
+func (s *Storage) SetAlias(name string, alias string) error {
+	if name == "" {
+		return fmt.Errorf("datasource name cannot be empty")
+	}
 
 	bucketKey := buildBucketKey(s.account, currentDBVersion)
 	log.Debug().
-		Str("name", ds.Name).
-		Int64("timestamp", ds.LRU).
-		Msg("Updating last used timestamp")
+		Str("name", name).
+		Str("alias", alias).
+		Msg("Updating datasource alias")
 
 	return s.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(bucketKey)
@@ -285,7 +591,41 @@ func (s *Storage) UpdateLastUsed(ds DataSource) error {
 			return ErrBucketNotFound
 		}
 
-		// Encode and store
+		value := bucket.Get([]byte(name))
+		if value == nil {
+			return ErrDataSourceNotFound
+		}
+
+		if alias != "" {
+			if alias != name && bucket.Get([]byte(alias)) != nil {
+				return fmt.Errorf("%w: %s is the name of another datasource", ErrAliasInUse, alias)
+			}
+
+			err := bucket.ForEach(func(k, v []byte) error {
+				if string(k) == name {
+					return nil
+				}
+				var other DataSource
+				if err := other.Decode(v); err != nil {
+					return nil // Skip undecodable entries
+				}
+				if other.Alias == alias {
+					return fmt.Errorf("%w: %s is already an alias of %s", ErrAliasInUse, alias, other.Name)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var ds DataSource
+		if err := ds.Decode(value); err != nil {
+			return fmt.Errorf("failed to decode datasource: %w", err)
+		}
+
+		ds.Alias = alias
+
 		encodedData, err := ds.Encode()
 		if err != nil {
 			return fmt.Errorf("failed to encode datasource: %w", err)
@@ -299,6 +639,26 @@ func (s *Storage) UpdateLastUsed(ds DataSource) error {
 	})
 }
 
+// FindByAlias retrieves the datasource with the given alias
+func (s *Storage) FindByAlias(alias string) (DataSource, error) {
+	if alias == "" {
+		return DataSource{}, fmt.Errorf("alias cannot be empty")
+	}
+
+	dataSources, err := s.RetrieveDatasources()
+	if err != nil {
+		return DataSource{}, err
+	}
+
+	for _, ds := range dataSources {
+		if ds.Alias == alias {
+			return ds, nil
+		}
+	}
+
+	return DataSource{}, ErrDataSourceNotFound
+}
+
 // removeOldBuckets removes buckets older than the retention period
 func (s *Storage) removeOldBuckets(retentionPeriod int) error {
 	log.Debug().Int("retention_period", retentionPeriod).Msg("Removing old buckets")
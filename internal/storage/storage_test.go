@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStoreServers_Reconcile(t *testing.T) {
+	db, err := NewStorage("me@example.com", t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	result, err := db.StoreServers([]DataSource{
+		{Name: "payments-db", Address: "localhost:10001", Tags: map[string]string{"env": "prod"}},
+		{Name: "grafana", Address: "localhost:10002"},
+		{Name: "legacy-db", Address: "localhost:10003"},
+	}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"grafana", "legacy-db", "payments-db"}, result.Added)
+	assert.Empty(t, result.Removed)
+
+	require.NoError(t, db.SetPinned("payments-db", true))
+	require.NoError(t, db.SetAlias("payments-db", "pay"))
+	require.NoError(t, db.UpdateLastUsed(DataSource{Name: "grafana", Address: "localhost:10002"}))
+
+	result, err = db.StoreServers([]DataSource{
+		{Name: "payments-db", Address: "localhost:10001", Tags: map[string]string{"env": "staging"}, Status: "connected"},
+		{Name: "grafana", Address: "localhost:10002", Status: "connected"},
+		{Name: "redis", Address: "localhost:10004"},
+	}, true)
+	require.NoError(t, err)
+	assert.Equal(t, SyncResult{
+		Total:   3,
+		Added:   []string{"redis"},
+		Removed: []string{"legacy-db"},
+		Changed: []string{"payments-db"},
+	}, result, "a new connection status alone should not count as a change")
+
+	_, err = db.GetDatasource("legacy-db")
+	assert.ErrorIs(t, err, ErrDataSourceNotFound, "vanished resources should be removed")
+
+	payments, err := db.GetDatasource("payments-db")
+	require.NoError(t, err)
+	assert.True(t, payments.Pinned)
+	assert.Equal(t, "pay", payments.Alias)
+	assert.Equal(t, "staging", payments.Tags["env"])
+
+	grafana, err := db.GetDatasource("grafana")
+	require.NoError(t, err)
+	assert.Equal(t, 1, grafana.UseCount, "usage of resources still listed should be kept")
+
+	syncedAt, err := db.LastSyncedAt()
+	require.NoError(t, err)
+	assert.False(t, syncedAt.IsZero())
+}
+
+func TestStoreServers_EmptyList(t *testing.T) {
+	tests := []struct {
+		name     string
+		complete bool
+		expected int
+	}{
+		{"incomplete status", false, 1},
+		{"every resource revoked", true, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := NewStorage("me@example.com", t.TempDir())
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.StoreServers([]DataSource{{Name: "payments-db"}}, true)
+			require.NoError(t, err)
+
+			result, err := db.StoreServers(nil, tc.complete)
+			require.NoError(t, err)
+			assert.Len(t, result.Removed, 1-tc.expected)
+
+			dataSources, err := db.RetrieveDatasources()
+			require.NoError(t, err)
+			assert.Len(t, dataSources, tc.expected)
+		})
+	}
+}
+
+func TestNewStorage_MigratesV2(t *testing.T) {
+	dir := t.TempDir()
+
+	// Version 2 stored the tags as a single string
+	legacy := struct {
+		Name    string
+		Status  string
+		Address string
+		Type    string
+		Tags    string
+		WebURL  string
+		LRU     int64
+	}{Name: "payments-db", Address: "localhost:10001", Type: "postgres", Tags: "env=prod", LRU: 1700000000}
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(legacy))
+
+	db, err := bolt.Open(filepath.Join(dir, "sdm-sources.db"), 0o600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket(buildBucketKey("me@example.com", 2))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(legacy.Name), buf.Bytes())
+	}))
+	require.NoError(t, db.Close())
+
+	store, err := NewStorage("me@example.com", dir)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ds, err := store.GetDatasource("payments-db")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), ds.LRU, "the usage history should survive the upgrade")
+	assert.Equal(t, "localhost:10001", ds.Address)
+	assert.Nil(t, ds.Tags, "tags are filled in by the next sync")
+
+	// Later syncs keep the migrated history
+	_, err = store.StoreServers([]DataSource{{Name: "payments-db", Tags: map[string]string{"env": "prod"}}}, true)
+	require.NoError(t, err)
+
+	ds, err = store.GetDatasource("payments-db")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), ds.LRU)
+}
+
+func TestSetAlias(t *testing.T) {
+	db, err := NewStorage("me@example.com", t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.StoreServers([]DataSource{{Name: "payments-db"}, {Name: "orders-db"}}, true)
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetAlias("payments-db", "pay"))
+	require.NoError(t, db.SetAlias("payments-db", "pay"), "setting the same alias again should not clash with itself")
+	require.NoError(t, db.SetAlias("orders-db", "orders-db"), "a datasource may be aliased to its own name")
+
+	err = db.SetAlias("orders-db", "pay")
+	assert.ErrorIs(t, err, ErrAliasInUse, "an alias of another datasource should be rejected")
+
+	err = db.SetAlias("orders-db", "payments-db")
+	assert.ErrorIs(t, err, ErrAliasInUse, "the name of another datasource should be rejected")
+
+	err = db.SetAlias("redis", "r")
+	assert.ErrorIs(t, err, ErrDataSourceNotFound)
+
+	ds, err := db.FindByAlias("pay")
+	require.NoError(t, err)
+	assert.Equal(t, "payments-db", ds.Name)
+
+	require.NoError(t, db.SetAlias("payments-db", ""))
+	_, err = db.FindByAlias("pay")
+	assert.ErrorIs(t, err, ErrDataSourceNotFound, "an empty alias should remove it")
+
+	require.NoError(t, db.SetAlias("orders-db", "pay"), "a removed alias should be free again")
+}
+
+func TestNewStorage_Shared(t *testing.T) {
+	dir := t.TempDir()
+
+	client, err := NewStorage("me@example.com", dir, WithShared())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.StoreServers([]DataSource{{Name: "payments-db"}}, true)
+	require.NoError(t, err)
+
+	// Another process can open the database while the client is idle
+	db, err := bolt.Open(filepath.Join(dir, "sdm-sources.db"), 0o600, &bolt.Options{Timeout: 100 * time.Millisecond})
+	require.NoError(t, err, "a shared database should not stay locked")
+	require.NoError(t, db.Close())
+
+	daemon, err := NewStorage("me@example.com", dir, WithShared())
+	require.NoError(t, err)
+	_, err = daemon.StoreServers([]DataSource{{Name: "payments-db"}, {Name: "orders-db"}}, true)
+	require.NoError(t, err)
+	require.NoError(t, daemon.Close())
+
+	dataSources, err := client.RetrieveDatasources()
+	require.NoError(t, err)
+	assert.Len(t, dataSources, 2, "the client should read what the daemon stored")
+}